@@ -2,57 +2,145 @@ package bootstrap
 
 import (
 	"context"
-	"fmt"
 	"os"
 
 	_ "github.com/adrg/xdg"
 	_ "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/x/term"
+	"github.com/fatih/color"
 	"github.com/pubgo/dix/v2"
 	"github.com/pubgo/dix/v2/dixcontext"
+	"github.com/pubgo/fastcommit/cmds/chglogcmd"
 	"github.com/pubgo/fastcommit/cmds/configcmd"
 	"github.com/pubgo/fastcommit/cmds/fastcommitcmd"
 	"github.com/pubgo/fastcommit/cmds/historycmd"
+	"github.com/pubgo/fastcommit/cmds/hookcmd"
+	"github.com/pubgo/fastcommit/cmds/lintcmd"
 	"github.com/pubgo/fastcommit/cmds/pullcmd"
 	"github.com/pubgo/fastcommit/cmds/tagcmd"
 	"github.com/pubgo/fastcommit/cmds/upgradecmd"
 	"github.com/pubgo/fastcommit/cmds/versioncmd"
 	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/llmclient"
 	"github.com/pubgo/funk/v2/assert"
 	"github.com/pubgo/funk/v2/config"
 	"github.com/pubgo/funk/v2/errors"
 	"github.com/pubgo/funk/v2/log"
 	"github.com/pubgo/funk/v2/recovery"
 	"github.com/pubgo/redant"
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
 	_ "github.com/sashabaranov/go-openai"
 )
 
+const (
+	exitCodeError     = 1
+	exitCodeCancelled = 130
+)
+
+var globalFlags = new(struct {
+	yes    bool
+	output string
+	dryRun bool
+})
+
+// globalOptions are added to every command, redant only shares its builtin root flags with the children
+func globalOptions() redant.OptionSet {
+	return redant.OptionSet{
+		{
+			Flag:        "yes",
+			Shorthand:   "y",
+			Envs:        []string{"FASTCOMMIT_YES"},
+			Description: "Run non-interactively, every prompt takes its default, implied when stdin or stdout is not a terminal.",
+			Value:       redant.BoolOf(&globalFlags.yes),
+		},
+		{
+			Flag:        "non-interactive",
+			Description: "Alias of --yes.",
+			Value:       redant.BoolOf(&globalFlags.yes),
+		},
+		{
+			Flag:        "output",
+			Envs:        []string{"FASTCOMMIT_OUTPUT"},
+			Description: "Output format of the command result: text or json, json implies --yes.",
+			Default:     utils.OutputText,
+			Value:       redant.EnumOf(&globalFlags.output, utils.OutputText, utils.OutputJSON),
+		},
+		{
+			Flag:        "dry-run",
+			Envs:        []string{"FASTCOMMIT_DRY_RUN"},
+			Description: "Print the git commands which change the repository or the remote instead of running them.",
+			Value:       redant.BoolOf(&globalFlags.dryRun),
+		},
+	}
+}
+
+// addGlobalOptions hides the options of the sub commands, the help of a sub command lists the options of its parents
+func addGlobalOptions(cmds []*redant.Command, hidden bool) {
+	for _, cmd := range cmds {
+		for _, opt := range globalOptions() {
+			opt.Hidden = hidden
+			cmd.Options = append(cmd.Options, opt)
+		}
+		addGlobalOptions(cmd.Children, true)
+	}
+}
+
+// initNonInteractive switches to json logs without colors, so that scripts and editors can parse the output
+func initNonInteractive() {
+	utils.SetNonInteractive(globalFlags.yes || utils.DetectNonInteractive())
+	utils.SetOutputFormat(globalFlags.output)
+	utils.SetDryRun(globalFlags.dryRun)
+	if utils.IsInteractive() {
+		return
+	}
+
+	color.NoColor = true
+	log.SetLogger(lo.ToPtr(zerolog.New(os.Stderr).With().Timestamp().Logger()))
+}
+
+// initGit answers the read-only git commands from go-git when FASTCOMMIT_GIT_BACKEND=go-git,
+// outside of a repository the git binary reports the error
+func initGit() {
+	if os.Getenv("FASTCOMMIT_GIT_BACKEND") != "go-git" {
+		return
+	}
+
+	g, err := utils.NewGoGit(".", utils.GetGit())
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to open the repository with go-git, fall back to the git binary")
+		return
+	}
+	utils.SetGit(g)
+}
+
 func Main() {
 	run(
 		versioncmd.New(),
 		upgradecmd.New(),
 		tagcmd.New(),
+		chglogcmd.New(),
 		historycmd.New(),
 		fastcommitcmd.New(),
 		configcmd.New(),
 		pullcmd.New(),
+		lintcmd.New(),
+		hookcmd.New(),
 	)
 }
 
 func run(cmds ...*redant.Command) {
 	defer recovery.Exit(func(err error) error {
-		if errors.Is(err, context.Canceled) {
-			return nil
-		}
-
-		if err.Error() == "signal: interrupt" {
-			return nil
+		if errors.Is(err, context.Canceled) || err.Error() == "signal: interrupt" {
+			os.Exit(exitCodeCancelled)
 		}
 
 		log.Err(err).Msg("failed to run command")
+		os.Exit(exitCodeError)
 		return nil
 	})
 
+	addGlobalOptions(cmds, false)
+
 	app := &redant.Command{
 		Use:      "fastcommit",
 		Short:    "Intelligent generation of git commit message",
@@ -63,14 +151,13 @@ func run(cmds ...*redant.Command) {
 					return redant.DefaultHelpFn()(ctx, i)
 				}
 
-				if !term.IsTerminal(os.Stdin.Fd()) {
-					return fmt.Errorf("stdin is not terminal")
-				}
-
+				initNonInteractive()
+				initGit()
 				initConfig()
 				di := dix.New(dix.WithValuesNull())
 				di.Provide(config.Load[configProvider])
 				di.Provide(utils.NewOpenaiClient)
+				di.Provide(llmclient.New)
 				return next(dixcontext.Create(ctx, di), i)
 			}
 		},
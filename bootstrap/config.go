@@ -16,12 +16,17 @@ import (
 	"github.com/pubgo/fastcommit/cmds/fastcommitcmd"
 	"github.com/pubgo/fastcommit/configs"
 	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/gitsync"
+	"github.com/pubgo/fastcommit/utils/llmclient"
 )
 
 type configProvider struct {
 	Version      *configs.Version      `yaml:"version"`
+	LlmConfig    *llmclient.Config     `yaml:"llm"`
 	OpenaiConfig *utils.OpenaiConfig   `yaml:"openai"`
 	CommitConfig *fastcommitcmd.Config `yaml:"commit"`
+	SyncConfig   *gitsync.Config       `yaml:"sync"`
+	TagConfig    *utils.TagConfig      `yaml:"tag"`
 }
 
 func initConfig() {
@@ -45,6 +50,7 @@ func initConfig() {
 	if pathutil.IsNotExist(configPath) {
 		assert.Must(os.WriteFile(configPath, configs.GetDefaultConfig(), 0644))
 		assert.Must(os.WriteFile(envPath, configs.GetEnvConfig(), 0644))
+		config.SetConfigPath(configPath)
 		return
 	}
 
@@ -1,3 +1,191 @@
 package chglogcmd
 
 // https://github.com/goreleaser/chglog/blob/main/pkg/commands/format.go
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/pubgo/dix/v2"
+	"github.com/pubgo/dix/v2/dixcontext"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/pubgo/funk/v2/pathutil"
+	"github.com/pubgo/funk/v2/result"
+	"github.com/pubgo/redant"
+	"github.com/samber/lo"
+
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+const summaryPrompt = `You write the summary of a release note.
+You get the changelog of the release, grouped by the type of the changes.
+Write one short paragraph in plain prose for the users of the project: what the release brings and what they have to watch out for.
+Do not list every change, do not use headings, lists or code fences.`
+
+func New() *redant.Command {
+	var flags = new(struct {
+		from    string
+		to      string
+		tag     string
+		file    string
+		stdout  bool
+		summary bool
+	})
+
+	return &redant.Command{
+		Use:   "changelog",
+		Short: "generate the changelog of the conventional commits between two refs into CHANGELOG.md",
+		Long: "The commits are grouped like the changelog groups of .goreleaser.yaml, issues and PRs are linked.\n" +
+			"The section of the version is inserted above the previous versions, or replaced when it exists.",
+		Options: []redant.Option{
+			{
+				Flag:        "from",
+				Description: "Start ref (exclusive), default: the latest release tag.",
+				Value:       redant.StringOf(&flags.from),
+			},
+			{
+				Flag:        "to",
+				Description: "End ref (inclusive).",
+				Default:     "HEAD",
+				Value:       redant.StringOf(&flags.to),
+			},
+			{
+				Flag:        "tag",
+				Description: "Version of the section, default: --to, or the planned next version when --to is HEAD.",
+				Value:       redant.StringOf(&flags.tag),
+			},
+			{
+				Flag:        "file",
+				Description: "Changelog file.",
+				Default:     "CHANGELOG.md",
+				Value:       redant.StringOf(&flags.file),
+			},
+			{
+				Flag:        "stdout",
+				Description: "Print the section instead of writing the changelog file.",
+				Value:       redant.BoolOf(&flags.stdout),
+			},
+			{
+				Flag:        "summary",
+				Envs:        []string{"FASTCOMMIT_CHANGELOG_SUMMARY"},
+				Description: "Let the llm write a summary paragraph at the top of the section.",
+				Value:       redant.BoolOf(&flags.summary),
+			},
+		},
+		Handler: func(ctx context.Context, i *redant.Invocation) error {
+			// redant shows the defaults in the help only
+			flags.to = lo.CoalesceOrEmpty(flags.to, "HEAD")
+			flags.file = lo.CoalesceOrEmpty(flags.file, "CHANGELOG.md")
+
+			from := flags.from
+			var current *semver.Version
+			if from == "" {
+				if current = utils.GetLatestReleaseTag(utils.GetAllGitTags(ctx)); current != nil {
+					from = current.Original()
+				}
+			} else {
+				current, _ = semver.NewSemver(from)
+			}
+
+			revs := lo.Ternary(from == "", flags.to, from+".."+flags.to)
+			commits, err := utils.GetConventionalCommits(ctx, revs)
+			if err != nil {
+				return err
+			}
+
+			version := flags.tag
+			switch {
+			case version != "":
+			case flags.to == "HEAD":
+				changed, err := utils.CountCommits(ctx, revs)
+				if err != nil {
+					return err
+				}
+
+				plan := utils.PlanVersion(current, changed, commits)
+				if plan.Unchanged() {
+					return errors.Errorf("no commit since %s, nothing to add to the changelog", from)
+				}
+				version = plan.Next.Original()
+			default:
+				version = flags.to
+			}
+
+			chglog := utils.NewChangelog(version, from, flags.to, utils.GetRepoURL(ctx), commits)
+			log.Info().Str("version", version).Str("revs", revs).Int("commits", len(commits)).Msg("changelog")
+
+			if flags.summary && len(chglog.Sections) > 0 {
+				if provider := newProvider(ctx); provider != nil {
+					chglog.Summary = utils.Spin("summarize the changelog: ", func() result.Result[string] {
+						return result.Wrap(summarize(ctx, provider, chglog))
+					}).Unwrap()
+				}
+			}
+
+			if utils.IsJSONOutput() {
+				return utils.PrintJSON(chglog)
+			}
+
+			section := chglog.Markdown()
+			if flags.stdout || utils.IsDryRun() {
+				fmt.Print(section)
+				return nil
+			}
+
+			var data []byte
+			if pathutil.IsExist(flags.file) {
+				if data, err = os.ReadFile(flags.file); err != nil {
+					return errors.Wrapf(err, "failed to read %s", flags.file)
+				}
+			}
+
+			if err := os.WriteFile(flags.file, utils.InsertChangelog(data, version, section), 0o644); err != nil {
+				return errors.Wrapf(err, "failed to write %s", flags.file)
+			}
+
+			log.Info().Str("file", flags.file).Str("version", version).Msg("changelog written")
+			return nil
+		},
+	}
+}
+
+// summarize asks the llm for the summary paragraph of the changelog
+func summarize(ctx context.Context, provider llmclient.Provider, chglog *utils.Changelog) (string, error) {
+	// the summary of a previous run is not part of the prompt
+	chglog.Summary = ""
+
+	resp, err := provider.Generate(ctx, &llmclient.Request{
+		Messages: []llmclient.Message{
+			{Role: llmclient.RoleSystem, Content: summaryPrompt},
+			{Role: llmclient.RoleUser, Content: chglog.Markdown()},
+		},
+		Temperature: lo.ToPtr(float32(0.3)),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to summarize the changelog")
+	}
+
+	log.Info().Any("usage", resp.Usage).Msg("changelog summarized")
+	return strings.TrimSpace(resp.Content()), nil
+}
+
+// newProvider returns the llm provider for the summary, nil when no llm is configured
+func newProvider(ctx context.Context) llmclient.Provider {
+	type providerParams struct {
+		Provider llmclient.Provider
+	}
+
+	params, err := result.Try(func() providerParams {
+		return dix.InjectT[providerParams](dixcontext.Get(ctx))
+	}).UnwrapErr()
+	if err != nil {
+		log.Warn().Err(err).Msg("no llm provider, the changelog has no summary")
+		return nil
+	}
+	return params.Provider
+}
@@ -3,7 +3,9 @@ package configcmd
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 
 	"github.com/a8m/envsubst"
 	"github.com/joho/godotenv"
@@ -20,8 +22,24 @@ import (
 	"github.com/pubgo/funk/v2/strutil"
 	"github.com/pubgo/redant"
 	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
 )
 
+// showOutput is printed to stdout by config show --output json
+type showOutput struct {
+	Path   string            `json:"path"`
+	Config map[string]any    `json:"config,omitempty"`
+	Env    []envItem         `json:"env,omitempty"`
+	Local  map[string]string `json:"local,omitempty"`
+}
+
+type envItem struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+}
+
 func New() *redant.Command {
 	return &redant.Command{
 		Use:   "config",
@@ -31,6 +49,10 @@ func New() *redant.Command {
 				Use:   "edit",
 				Short: "edit config, env or local env file, args: [config|env|local], default:config",
 				Handler: func(ctx context.Context, i *redant.Invocation) error {
+					if !utils.IsInteractive() {
+						return utils.ErrNonInteractive
+					}
+
 					command := i.Command
 					args := command.Args
 					if len(args) == 0 {
@@ -74,6 +96,12 @@ func New() *redant.Command {
 						cfgData := assert.Must1(os.ReadFile(cfgPath))
 						cfgData = assert.Must1(envsubst.Bytes(cfgData))
 
+						if utils.IsJSONOutput() {
+							var cfg map[string]any
+							assert.Must(yaml.Unmarshal(cfgData, &cfg))
+							return utils.PrintJSON(&showOutput{Path: cfgPath, Config: cfg})
+						}
+
 						log.Info().Msgf("config data: \n%s", cfgData)
 						return nil
 					}
@@ -90,11 +118,29 @@ func New() *redant.Command {
 							}
 						}
 
+						if utils.IsJSONOutput() {
+							var envs = make([]envItem, 0, len(envMap))
+							for _, name := range slices.Sorted(maps.Keys(envMap)) {
+								cfg := envMap[name]
+								envs = append(envs, envItem{
+									Name:        name,
+									Value:       cfg.Value,
+									Default:     cfg.Default,
+									Description: strutil.FirstNotEmpty(cfg.Desc, cfg.Description),
+								})
+							}
+							return utils.PrintJSON(&showOutput{Path: configs.GetEnvPath(), Env: envs})
+						}
+
 						pretty.Println(lo.Values(envMap))
 					case "local":
 						log.Info().Msgf("local env path: %s", configs.GetLocalEnvPath())
 						data := result.Wrap(os.ReadFile(configs.GetLocalEnvPath())).Unwrap()
 						dataMap := result.Wrap(godotenv.UnmarshalBytes(data)).Unwrap()
+						if utils.IsJSONOutput() {
+							return utils.PrintJSON(&showOutput{Path: configs.GetLocalEnvPath(), Local: dataMap})
+						}
+
 						pretty.Println(dataMap)
 					}
 
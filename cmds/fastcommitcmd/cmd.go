@@ -1,16 +1,12 @@
 package fastcommitcmd
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/pubgo/dix/v2"
 	"github.com/pubgo/dix/v2/dixcontext"
 	"github.com/pubgo/funk/v2/assert"
@@ -19,25 +15,114 @@ import (
 	"github.com/pubgo/funk/v2/pathutil"
 	"github.com/pubgo/funk/v2/result"
 	"github.com/pubgo/redant"
-	"github.com/sashabaranov/go-openai"
+	"github.com/samber/lo"
 	"github.com/yarlson/tap"
 
+	"github.com/pubgo/fastcommit/configs"
 	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/gitsync"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+const (
+	defaultMaxDiffTokens = 12000
+	defaultLocale        = "en"
+	defaultMaxLength     = 50
 )
 
 type Config struct {
 	GenVersion bool `yaml:"gen_version"`
+
+	// MaxDiffTokens is the token budget of the diff sent to the llm, default: 12000
+	MaxDiffTokens int `yaml:"max_diff_tokens"`
+
+	// Locale is the language of the commit message, default: en
+	Locale string `yaml:"locale"`
+
+	// MaxLength is the max length of the commit message subject, default: 50
+	MaxLength int `yaml:"max_length"`
+
+	// CommitType is the commit scheme: conventional, angular, gitmoji or the name of a custom scheme
+	CommitType utils.CommitType `yaml:"commit_type"`
+
+	// Body generates a subject, a body and trailers instead of a single line
+	Body bool `yaml:"body"`
+
+	// Schemes are custom commit schemes, selected by commit_type
+	Schemes []*utils.CommitScheme `yaml:"schemes"`
+}
+
+// the status of the commit result
+const (
+	commitStatusCommitted       = "committed"
+	commitStatusNothingToCommit = "nothing_to_commit"
+)
+
+// errCommitAborted is returned when the user empties the message or cancels a prompt, it exits with the cancel exit code
+var errCommitAborted = errors.Wrap(context.Canceled, "the commit is aborted")
+
+// commitResult is printed to stdout by --output json
+type commitResult struct {
+	Status   string             `json:"status"`
+	Message  string             `json:"message"`
+	Commit   string             `json:"commit"`
+	Files    []string           `json:"files,omitempty"`
+	Excluded []string           `json:"excluded,omitempty"`
+	Provider string             `json:"provider,omitempty"`
+	Model    string             `json:"model,omitempty"`
+	Usage    *llmclient.Usage   `json:"usage,omitempty"`
+	Issues   []*utils.LintIssue `json:"lint_issues,omitempty"`
+	Prompt   string             `json:"prompt,omitempty"`
+	Pushed   bool               `json:"pushed"`
+	DryRun   bool               `json:"dry_run,omitempty"`
+
+	PushStatus utils.PushStatus `json:"push_status,omitempty"`
+}
+
+// printCommitResult fills the commit hash and prints the result, it does nothing in text output
+func printCommitResult(res *commitResult) error {
+	if !utils.IsJSONOutput() {
+		return nil
+	}
+
+	res.Status = commitStatusCommitted
+
+	if utils.IsDryRun() {
+		res.Pushed, res.DryRun = false, true
+		return utils.PrintJSON(res)
+	}
+
+	var err error
+	res.Commit, err = utils.HeadCommit()
+	if err != nil {
+		return errors.WrapCaller(err)
+	}
+	return utils.PrintJSON(res)
+}
+
+// nothingToCommit tells why nothing is committed, in json output by the status nothing_to_commit
+func nothingToCommit(reason string) error {
+	log.Info().Msg("nothing to commit, " + reason)
+	if !utils.IsJSONOutput() {
+		return nil
+	}
+	return utils.PrintJSON(&commitResult{Status: commitStatusNothingToCommit, DryRun: utils.IsDryRun()})
 }
 
 type cmdParams struct {
-	OpenaiClient *utils.OpenaiClient
-	CommitCfg    []*Config
+	Provider  llmclient.Provider
+	CommitCfg []*Config
+	SyncCfg   *gitsync.Config
 }
 
 func New() *redant.Command {
 	var flags = new(struct {
 		showPrompt bool
 		fastCommit bool
+		noStream   bool
+		candidates int64
+		mapReduce  bool
+		body       bool
 	})
 
 	app := &redant.Command{
@@ -54,23 +139,34 @@ func New() *redant.Command {
 				Description: "Quickly generate messages without prompts.",
 				Value:       redant.BoolOf(&flags.fastCommit),
 			},
+			{
+				Flag:        "no-stream",
+				Description: "Wait for the whole message instead of streaming it.",
+				Value:       redant.BoolOf(&flags.noStream),
+			},
+			{
+				Flag:        "candidates",
+				Description: "Number of candidate messages to choose from.",
+				Default:     "1",
+				Value:       redant.Int64Of(&flags.candidates),
+			},
+			{
+				Flag:        "map-reduce",
+				Description: "Summarize every file separately before generating the message.",
+				Value:       redant.BoolOf(&flags.mapReduce),
+			},
+			{
+				Flag:        "body",
+				Description: "Generate a subject, a body and trailers, and edit them in $EDITOR.",
+				Value:       redant.BoolOf(&flags.body),
+			},
 		},
 		Handler: func(ctx context.Context, i *redant.Invocation) (gErr error) {
 			di := dixcontext.Get(ctx)
 			var params cmdParams
 			params = dix.Inject(di, params)
 
-			defer result.RecoveryErr(&gErr, func(err error) error {
-				if errors.Is(err, context.Canceled) {
-					return nil
-				}
-
-				if err.Error() == "signal: interrupt" {
-					return nil
-				}
-
-				return err
-			})
+			defer result.RecoveryErr(&gErr)
 
 			command := i.Command
 			if len(command.Args) > 0 {
@@ -80,25 +176,15 @@ func New() *redant.Command {
 
 			utils.LogConfigAndBranch()
 
-			res := utils.PreGitPush(ctx)
-			if res != "" {
-				if shouldPullDueToRemoteUpdate(res) {
-					err := gitPull()
-					if err != nil {
-						if isMergeConflict() {
-							handleMergeConflict()
-						} else {
-							os.Exit(1)
-						}
-					} else {
-						informUserToAmendAndPush()
-					}
+			if res := result.Wrap(utils.PreGitPush(ctx)).Unwrap(); res != nil {
+				if err := params.recoverPush(ctx, res, "--force-with-lease", "origin", utils.GetBranchName()); err != nil {
+					return err
 				}
 			}
 
 			isDirty := utils.IsDirty().Unwrap()
 			if !isDirty {
-				return
+				return nothingToCommit("the working tree is clean")
 			}
 
 			if pathutil.IsNotExist(".version") {
@@ -107,12 +193,13 @@ func New() *redant.Command {
 						continue
 					}
 
-					allTags := utils.GetAllGitTags(ctx)
-					tagName := "v0.0.1"
-					if len(allTags) > 0 {
-						ver := utils.GetNextReleaseTag(allTags)
-						tagName = "v" + strings.TrimPrefix(ver.Original(), "v")
+					plan := result.Wrap(utils.GetVersionPlan(ctx)).Unwrap()
+					if plan.Unchanged() {
+						// the commit which is made now is the change of the release
+						plan = utils.PlanVersion(plan.Current, 1, nil)
 					}
+
+					tagName := "v" + strings.TrimPrefix(plan.Next.Original(), "v")
 					assert.Exit(os.WriteFile(".version", []byte(tagName), 0644))
 					break
 				}
@@ -125,7 +212,7 @@ func New() *redant.Command {
 				prefixMsg := fmt.Sprintf("chore: quick update %s", utils.GetBranchName())
 				msg := fmt.Sprintf("%s at %s", prefixMsg, time.Now().Format(time.DateTime))
 
-				msg = strings.TrimSpace(tap.Text(ctx, tap.TextOptions{
+				msg = strings.TrimSpace(utils.PromptText(ctx, tap.TextOptions{
 					Message:      "git message(update or enter):",
 					InitialValue: msg,
 					DefaultValue: msg,
@@ -133,38 +220,34 @@ func New() *redant.Command {
 				}))
 
 				if msg == "" {
-					return
+					return errCommitAborted
 				}
 
 				assert.Must(utils.ShellExec(ctx, "git", "add", "-A"))
-				res := utils.ShellExecOutput(ctx, "git", "status").Unwrap()
-				if strings.Contains(preMsg, prefixMsg) && !strings.Contains(res, `(use "git commit" to conclude merge)`) {
-					assert.Must(utils.ShellExec(ctx, "git", "commit", "--amend", "--no-edit", "-m", strconv.Quote(msg)))
+				status := result.Wrap(utils.GetRepoStatus(ctx)).Unwrap()
+				if strings.Contains(preMsg, prefixMsg) && !status.Merging {
+					assert.Must(utils.CommitAmend(msg))
 				} else {
-					assert.Must(utils.ShellExec(ctx, "git", "commit", "-m", strconv.Quote(msg)))
+					assert.Must(utils.Commit(msg))
 				}
 
-				res = utils.GitPush(ctx, "--force-with-lease", "origin", utils.GetBranchName())
-				if shouldPullDueToRemoteUpdate(res) {
-					err := gitPull()
-					if err != nil {
-						if isMergeConflict() {
-							handleMergeConflict()
-						} else {
-							os.Exit(1)
-						}
-					} else {
-						informUserToAmendAndPush()
-					}
+				pushArgs := []string{"--force-with-lease", "origin", utils.GetBranchName()}
+				pushRes := utils.GitPush(ctx, pushArgs...)
+				if pushRes.NeedPull() {
+					return params.recoverPush(ctx, pushRes, pushArgs...)
+				}
+
+				if err := printCommitResult(&commitResult{Message: msg, Pushed: pushRes.OK(), PushStatus: pushRes.Status()}); err != nil {
+					return err
 				}
-				return
+				return pushRes.AsError()
 			}
 
 			assert.Must(utils.ShellExec(ctx, "git", "add", "--update"))
 
-			diff := utils.GetStagedDiff(ctx).Unwrap()
+			diff := utils.GetStagedDiff(ctx, utils.NewDiffExcluder(configs.GetRepoPath())).Unwrap()
 			if diff == nil || len(diff.Files) == 0 {
-				return nil
+				return nothingToCommit("only untracked files are changed, stage them with git add")
 			}
 
 			log.Info().Msg(utils.GetDetectedMessage(diff.Files))
@@ -172,161 +255,216 @@ func New() *redant.Command {
 				log.Info().Msg("file: " + file)
 			}
 
-			s := spinner.New(spinner.CharSets[35], 100*time.Millisecond, func(s *spinner.Spinner) {
-				s.Prefix = "generate git message: "
-			})
-			s.Start()
-			generatePrompt := utils.GeneratePrompt("en", 50, utils.ConventionalCommitType)
-			resp, err := params.OpenaiClient.Client.CreateChatCompletion(
-				ctx,
-				openai.ChatCompletionRequest{
-					Model: params.OpenaiClient.Cfg.Model,
-					Messages: []openai.ChatCompletionMessage{
-						{
-							Role:    openai.ChatMessageRoleSystem,
-							Content: generatePrompt,
-						},
-						{
-							Role:    openai.ChatMessageRoleUser,
-							Content: diff.Diff,
-						},
-					},
-				},
-			)
-			s.Stop()
+			if len(diff.Excluded) > 0 {
+				log.Info().Strs("excluded", diff.Excluded).Msg("files excluded from the diff")
+			}
 
+			linter, err := NewLinter(params.CommitCfg)
 			if err != nil {
-				log.Err(err).Msg("failed to call openai")
+				log.Err(err).Msg("failed to load commit scheme")
 				return errors.WrapCaller(err)
 			}
 
-			if len(resp.Choices) == 0 {
-				return nil
+			withBody := flags.body || params.body()
+			req, usage, err := params.buildRequest(ctx, diff, linter.Scheme, flags.mapReduce, withBody)
+			if err != nil {
+				return errors.WrapCaller(err)
 			}
 
-			msg := resp.Choices[0].Message.Content
-			msg = strings.TrimSpace(tap.Text(ctx, tap.TextOptions{
-				Message:      "git message(update or enter):",
-				InitialValue: msg,
-				DefaultValue: msg,
-				Placeholder:  "update or enter",
-			}))
+			var msg string
+			for attempt := 0; ; attempt++ {
+				resp, err := generateMessage(ctx, params.Provider, req, int(flags.candidates), !flags.noStream && utils.IsInteractive())
+				if errors.Is(err, context.Canceled) {
+					log.Warn().Msg("generate git message cancelled")
+					return errors.WrapCaller(err)
+				}
+
+				if err != nil {
+					log.Err(err).Str("provider", params.Provider.Name()).Msg("failed to call llm provider")
+					return errors.WrapCaller(err)
+				}
+
+				usage = usage.Add(resp.Usage)
+				if resp.Content() == "" {
+					return errors.Errorf("the llm provider %s returned an empty git message", params.Provider.Name())
+				}
+
+				if flags.candidates <= 1 {
+					msg = resp.Content()
+					break
+				}
+
+				var regenerate bool
+				msg, regenerate = selectCandidate(ctx, resp.Choices, linter)
+				if !regenerate {
+					break
+				}
+
+				req.Temperature = lo.ToPtr(regenerateTemperatures[attempt%len(regenerateTemperatures)])
+				log.Info().Float32("temperature", *req.Temperature).Msg("regenerate git message")
+			}
 
 			if msg == "" {
-				return
+				return errCommitAborted
+			}
+
+			if issues := linter.Lint(msg); len(issues) > 0 {
+				log.Warn().Str("issues", utils.FormatLintIssues(issues)).Msg("git message does not pass the lint, ask for a fix")
+
+				resp, err := fixMessage(ctx, params.Provider, req, msg, issues)
+				if errors.Is(err, context.Canceled) {
+					log.Warn().Msg("fix git message cancelled")
+					return errors.WrapCaller(err)
+				}
+
+				if err != nil {
+					log.Err(err).Str("provider", params.Provider.Name()).Msg("failed to fix git message")
+				} else {
+					usage = usage.Add(resp.Usage)
+					if len(linter.Lint(resp.Content())) < len(issues) {
+						msg = resp.Content()
+					}
+				}
+			}
+
+			if withBody {
+				msg, err = editBody(ctx, linter, utils.ParseCommitMessage(msg).String())
+				if err != nil {
+					return errors.WrapCaller(err)
+				}
+
+				if msg == "" {
+					return errCommitAborted
+				}
+
+				assert.Must(utils.CommitFile(msg))
+			} else {
+				msg = editSubject(ctx, linter, msg)
+				if msg == "" {
+					return errCommitAborted
+				}
+
+				assert.Must(utils.Commit(msg))
+			}
+			pushRes := utils.GitPush(ctx, "origin", utils.GetBranchName())
+			pushErr := params.recoverPush(ctx, pushRes, "origin", utils.GetBranchName())
+			log.Info().Str("provider", params.Provider.Name()).Str("model", params.Provider.Model()).Any("usage", usage).Msg("llm response usage")
+
+			if utils.IsJSONOutput() {
+				err := printCommitResult(&commitResult{
+					Message:    strings.TrimSpace(msg),
+					Files:      diff.Files,
+					Excluded:   diff.Excluded,
+					Provider:   params.Provider.Name(),
+					Model:      params.Provider.Model(),
+					Usage:      &usage,
+					Issues:     linter.Lint(msg),
+					Prompt:     lo.Ternary(flags.showPrompt, req.System(), ""),
+					Pushed:     pushRes.OK(),
+					PushStatus: pushRes.Status(),
+				})
+				return lo.CoalesceOrEmpty(pushErr, err)
 			}
 
-			assert.Must(utils.ShellExec(ctx, "git", "commit", "-m", strconv.Quote(msg)))
-			utils.GitPush(ctx, "origin", utils.GetBranchName())
 			if flags.showPrompt {
-				fmt.Println("\n" + generatePrompt + "\n")
+				fmt.Println("\n" + req.System() + "\n")
 			}
-			log.Info().Any("usage", resp.Usage).Msg("openai response usage")
-			return
+			return pushErr
 		},
 	}
 
 	return app
 }
 
-func shouldPullDueToRemoteUpdate(msg string) bool {
-	return strings.Contains(msg, "stale info") ||
-		strings.Contains(msg, "[rejected]") ||
-		strings.Contains(msg, "failed to push") ||
-		strings.Contains(msg, "remote rejected")
-}
-
-// 执行 git pull（默认 merge 模式）
-func gitPull() error {
-	cmd := exec.Command("git", "pull", "--no-rebase")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	return cmd.Run()
-}
-
-// 检查是否存在未解决的合并冲突（U=unmerged）
-func isMergeConflict() bool {
-	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
-	output, err := cmd.Output()
-	if err != nil {
-		return false
+func (p cmdParams) maxDiffTokens() int {
+	for _, cfg := range p.CommitCfg {
+		if cfg != nil && cfg.MaxDiffTokens > 0 {
+			return cfg.MaxDiffTokens
+		}
 	}
-	return len(strings.TrimSpace(string(output))) > 0
+	return defaultMaxDiffTokens
 }
 
-// 处理合并冲突：打开编辑器让用户解决
-func handleMergeConflict() {
-	fmt.Println("❌ Merge conflicts detected! Please resolve them.")
-
-	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
-	output, _ := cmd.Output()
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-
-	editor := getEditor()
+func (p cmdParams) body() bool {
+	return lo.ContainsBy(p.CommitCfg, func(cfg *Config) bool { return cfg != nil && cfg.Body })
+}
 
-	for _, file := range files {
-		if file == "" {
+// NewLinter creates the linter of the active commit scheme, it is shared by the commit and lint commands
+func NewLinter(cfgs []*Config) (*utils.Linter, error) {
+	var commitType = utils.ConventionalCommitType
+	var schemes []*utils.CommitScheme
+	for _, cfg := range cfgs {
+		if cfg == nil {
 			continue
 		}
-		fmt.Printf("📝 Conflict in file: %s\n", file)
 
-		editCmd := exec.Command(editor, file)
-		editCmd.Stdin = os.Stdin
-		editCmd.Stdout = os.Stdout
-		editCmd.Stderr = os.Stderr
+		commitType = lo.CoalesceOrEmpty(cfg.CommitType, commitType)
+		schemes = append(schemes, cfg.Schemes...)
+	}
 
-		fmt.Printf("Opening editor '%s'...\n", editor)
-		if err := editCmd.Run(); err != nil {
-			log.Printf("Failed to edit %s: %v", file, err)
-		}
+	// the scheme of .fastcommit/scheme.yaml in the repo takes precedence over the configured one
+	scheme, err := utils.ResolveCommitScheme(configs.GetRepoSchemePath(), commitType, schemes...)
+	if err != nil {
+		return nil, err
 	}
 
-	// 提示用户完成后续操作
-	informUserToAmendAndPush()
+	locale, maxLength := messageOptions(cfgs)
+	return &utils.Linter{
+		Scheme:     scheme,
+		MaxLength:  maxLength,
+		Imperative: strings.HasPrefix(locale, "en"),
+	}, nil
 }
 
-func getEditor() string {
-	if e := os.Getenv("EDITOR"); e != "" {
-		return e
-	}
-
-	if _, err := exec.LookPath("zed"); err == nil {
-		return "zed -w"
-	}
+func messageOptions(cfgs []*Config) (locale string, maxLength int) {
+	locale, maxLength = defaultLocale, defaultMaxLength
+	for _, cfg := range cfgs {
+		if cfg == nil {
+			continue
+		}
 
-	if _, err := exec.LookPath("code"); err == nil {
-		return "code -w"
+		locale = lo.CoalesceOrEmpty(cfg.Locale, locale)
+		maxLength = lo.CoalesceOrEmpty(cfg.MaxLength, maxLength)
 	}
+	return locale, maxLength
+}
 
-	if _, err := exec.LookPath("vim"); err == nil {
-		return "vim"
+// generatePrompt renders the prompt template of the repo, or of the user config dir, or the default one
+func (p cmdParams) generatePrompt(diff *utils.GetStagedDiffRsp, scheme *utils.CommitScheme, withBody bool) (string, error) {
+	locale, maxLength := messageOptions(p.CommitCfg)
+	var data = utils.NewPromptData(locale, maxLength, scheme)
+	data.Body = withBody
+	data.Branch = utils.GetBranchName()
+	data.Files = diff.Files
+	data.RecentLog, _ = utils.Log()
+
+	tmpl, tmplPath := utils.LoadPromptTemplate(configs.GetRepoPromptPath(), configs.GetPromptPath())
+	if tmplPath != "" {
+		log.Info().Str("path", tmplPath).Msg("use prompt template")
 	}
 
-	if _, err := exec.LookPath("nano"); err == nil {
-		return "nano"
-	}
-	return "vi"
+	return utils.RenderPrompt(tmpl, data)
 }
 
-// 提示用户如何继续
-func informUserToAmendAndPush() {
-	fmt.Println("\n----------------------------------------")
-	fmt.Println("🛠️  Conflict resolved or pulled successfully.")
-	fmt.Println("Now you can:")
-	fmt.Println("   1. Review changes")
-	fmt.Println("   2. Run 'git add .' to stage resolved files")
-	fmt.Println("   3. Run 'git commit' (do NOT use --amend yet unless you want to absorb merge)")
-	fmt.Println("   4. Then do:")
-	fmt.Println("      git push --force-with-lease")
-	fmt.Println("")
-	fmt.Println("💡 Tip: 如果你想保持单个 commit，可以在 merge 后做交互式 rebase：")
-	fmt.Println("    git reset HEAD~1   # 取消 merge commit")
-	fmt.Println("    git add .")
-	fmt.Println("    git commit --amend")
-	fmt.Println("    git push --force-with-lease")
-	fmt.Println("----------------------------------------")
-
-	fmt.Println("\nPress Enter after you're done...")
-	bufio.NewReader(os.Stdin).ReadBytes('\n')
+// recoverPush syncs with the remote when it has commits the push does not include and pushes again, other failures are returned
+func (p cmdParams) recoverPush(ctx context.Context, res *utils.PushResult, pushArgs ...string) error {
+	switch res.Status() {
+	case utils.PushOK, utils.PushUpToDate:
+		return nil
+	case utils.PushRejectedNonFastForward, utils.PushStaleLease:
+		strategy := p.SyncCfg.GetStrategy()
+		log.Warn().Str("status", string(res.Status())).Str("strategy", string(strategy)).Msg("the remote has new commits, pull them first")
+
+		// the merge commit is left for review before it is pushed
+		_, err := (&gitsync.Syncer{
+			Strategy: strategy,
+			Branch:   utils.GetBranchName(),
+			Resolve:  gitsync.NewResolver(func() llmclient.Provider { return p.Provider }),
+			PushArgs: lo.Ternary(strategy.IsRebase(), pushArgs, nil),
+			Retries:  1,
+		}).Run(ctx)
+		return err
+	default:
+		return res.AsError()
+	}
 }
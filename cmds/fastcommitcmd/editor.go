@@ -0,0 +1,176 @@
+package fastcommitcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/yarlson/tap"
+
+	"github.com/pubgo/fastcommit/utils"
+)
+
+// editorHelp is below the scissors line, so that the lines of the message starting with '#', e.g. markdown headings, are kept
+const editorHelp = utils.ScissorsLine + `
+# Do not modify or remove the line above.
+# Edit the commit message above it, the first line is the subject, an empty message aborts the commit.`
+
+// editMessage lets the user edit a multi-line message in $VISUAL, $EDITOR or a known editor, in a textarea when there is none
+func editMessage(ctx context.Context, msg string) (string, error) {
+	editor, err := utils.GetEditor().UnwrapErr()
+	if err != nil {
+		return editInTextarea(ctx, msg)
+	}
+	return editInEditor(ctx, editor, msg)
+}
+
+func editInEditor(ctx context.Context, editor string, msg string) (string, error) {
+	f, err := os.CreateTemp("", "COMMIT_EDITMSG-*")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create commit message file")
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(msg + "\n\n" + editorHelp + "\n")
+	f.Close()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to write commit message file")
+	}
+
+	// the editor may have arguments, e.g. "code -w", the path is passed as $1 so that the shell does not expand it
+	cmd := exec.CommandContext(ctx, "sh", "-c", editor+` "$1"`, "sh", f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "failed to run editor %q", editor)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read commit message file")
+	}
+	return string(data), nil
+}
+
+func editInTextarea(ctx context.Context, msg string) (string, error) {
+	ta := textarea.New()
+	ta.SetValue(msg)
+	ta.SetWidth(80)
+	ta.SetHeight(min(max(strings.Count(msg, "\n")+3, 8), 20))
+	ta.CharLimit = 0
+	ta.ShowLineNumbers = false
+	ta.Focus()
+
+	res, err := tea.NewProgram(textareaModel{textarea: ta}, tea.WithContext(ctx)).Run()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to run commit message editor")
+	}
+
+	m := res.(textareaModel)
+	if m.cancelled {
+		return "", nil
+	}
+	return m.textarea.Value(), nil
+}
+
+type textareaModel struct {
+	textarea  textarea.Model
+	cancelled bool
+}
+
+func (m textareaModel) Init() tea.Cmd { return textarea.Blink }
+
+func (m textareaModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.cancelled = true
+			return m, tea.Quit
+		case tea.KeyCtrlS, tea.KeyCtrlD:
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+func (m textareaModel) View() string {
+	help := lipgloss.NewStyle().Faint(true).Render("ctrl+s save • esc cancel")
+	return "git message(edit):\n" + m.textarea.View() + "\n" + help + "\n"
+}
+
+// editSubject lets the user edit the single line message, while the message does not pass the lint
+// the issues are shown and the user is asked again, submitting the same message again accepts it
+func editSubject(ctx context.Context, linter *utils.Linter, msg string) string {
+	if !utils.IsInteractive() {
+		msg = utils.ParseCommitMessage(msg).Subject
+		logLintIssues(linter.Lint(msg))
+		return msg
+	}
+
+	var title = "git message(update or enter):"
+	var warned bool
+	for {
+		edited := strings.TrimSpace(tap.Text(ctx, tap.TextOptions{
+			Message:      title,
+			InitialValue: msg,
+			DefaultValue: msg,
+			Placeholder:  "update or enter",
+		}))
+
+		issues := linter.Lint(edited)
+		if edited == "" || len(issues) == 0 || (warned && edited == msg) {
+			return edited
+		}
+
+		msg, warned = edited, true
+		title = fmt.Sprintf("git message(lint: %s, fix or enter to keep):", utils.FormatLintIssues(issues))
+	}
+}
+
+// editBody lets the user edit the multi-line message, while it does not pass the lint the user may edit it again
+func editBody(ctx context.Context, linter *utils.Linter, msg string) (string, error) {
+	if !utils.IsInteractive() {
+		logLintIssues(linter.Lint(msg))
+		return msg, nil
+	}
+
+	for {
+		edited, err := editMessage(ctx, msg)
+		if err != nil {
+			return "", err
+		}
+
+		commitMsg := utils.ParseCommitMessage(edited)
+		if commitMsg.Subject == "" {
+			return "", nil
+		}
+
+		msg = commitMsg.String()
+		issues := linter.Lint(msg)
+		if len(issues) == 0 {
+			return msg, nil
+		}
+
+		if !tap.Confirm(ctx, tap.ConfirmOptions{
+			Message:      fmt.Sprintf("git message(lint):\n%s\nedit again?", utils.FormatLintIssues(issues)),
+			InitialValue: true,
+		}) {
+			return msg, nil
+		}
+	}
+}
+
+func logLintIssues(issues []*utils.LintIssue) {
+	if len(issues) > 0 {
+		log.Warn().Str("issues", utils.FormatLintIssues(issues)).Msg("git message does not pass the lint")
+	}
+}
@@ -0,0 +1,193 @@
+package fastcommitcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/yarlson/tap"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+// buildRequest fits the staged diff into the token budget, or summarizes it, and renders the prompt
+func (p cmdParams) buildRequest(ctx context.Context, diff *utils.GetStagedDiffRsp, scheme *utils.CommitScheme, mapReduce, withBody bool) (*llmclient.Request, llmclient.Usage, error) {
+	var usage llmclient.Usage
+	maxDiffTokens := p.maxDiffTokens()
+	budget := utils.BudgetDiff(diff.Diff, maxDiffTokens, llmclient.NewTokenCounter(ctx, p.Provider, diff.Diff))
+	diffContent := budget.Diff
+	if mapReduce || len(budget.Omitted) > 0 {
+		log.Info().Int("max_tokens", maxDiffTokens).Strs("omitted", budget.Omitted).Msg("diff exceeds the token budget, summarize every file")
+
+		var err error
+		var summaryUsage llmclient.Usage
+		diffContent, summaryUsage, err = summarizeDiff(ctx, p.Provider, utils.ParseDiff(diff.Diff), maxDiffTokens/2)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Err(err).Str("provider", p.Provider.Name()).Msg("failed to summarize git diff")
+			}
+			return nil, usage, err
+		}
+
+		usage = usage.Add(summaryUsage)
+		diffContent = utils.Ellipse(diffContent, maxDiffTokens)
+	} else if len(budget.Truncated) > 0 {
+		log.Info().Int("max_tokens", maxDiffTokens).Strs("truncated", budget.Truncated).Msg("diff exceeds the token budget, drop hunks")
+	}
+
+	if note := diff.ExcludedNote(); note != "" {
+		diffContent = strings.TrimSpace(diffContent + "\n\n" + note)
+	}
+
+	generatePrompt, err := p.generatePrompt(diff, scheme, withBody)
+	if err != nil {
+		log.Err(err).Msg("failed to generate prompt")
+		return nil, usage, err
+	}
+
+	return &llmclient.Request{
+		Messages: []llmclient.Message{
+			{
+				Role:    llmclient.RoleSystem,
+				Content: generatePrompt,
+			},
+			{
+				Role:    llmclient.RoleUser,
+				Content: diffContent,
+			},
+		},
+	}, usage, nil
+}
+
+// regenerateTemperatures are used in turn when the user asks for other candidates
+var regenerateTemperatures = []float32{0.9, 0.5, 1.2}
+
+// generateMessage streams the generated message into the terminal, ctrl+c cancels the ctx and stops the stream,
+// several candidates can not be streamed and are generated behind a spinner
+func generateMessage(ctx context.Context, provider llmclient.Provider, req *llmclient.Request, candidates int, stream bool) (*llmclient.Response, error) {
+	if !stream || candidates > 1 {
+		s := spinner.New(spinner.CharSets[35], 100*time.Millisecond, func(s *spinner.Spinner) {
+			s.Prefix = "generate git message: "
+		})
+		s.Start()
+		defer s.Stop()
+		return llmclient.GenerateCandidates(ctx, provider, req, candidates)
+	}
+
+	preview := color.New(color.Faint)
+	fmt.Fprintf(os.Stdout, "generate git message(%s/%s, ctrl+c to cancel):\n", provider.Name(), provider.Model())
+	resp, err := provider.Stream(ctx, req, func(delta string) { _, _ = preview.Fprint(os.Stdout, delta) })
+	fmt.Fprintln(os.Stdout)
+	return resp, err
+}
+
+// selectCandidate lets the user pick one of the candidates or ask for new ones,
+// option values start at 1 because a cancelled select returns 0
+func selectCandidate(ctx context.Context, choices []string, linter *utils.Linter) (msg string, regenerate bool) {
+	const regenerateIndex = -1
+
+	var options []tap.SelectOption[int]
+	for i, choice := range choices {
+		label, _, _ := strings.Cut(strings.TrimSpace(choice), "\n")
+		option := tap.SelectOption[int]{Value: i + 1, Label: label}
+		if issues := linter.Lint(choice); len(issues) > 0 {
+			option.Hint = issues[0].String()
+		}
+		options = append(options, option)
+	}
+	options = append(options, tap.SelectOption[int]{
+		Value: regenerateIndex,
+		Label: "regenerate",
+		Hint:  "generate new candidates with a different temperature",
+	})
+
+	index := utils.PromptSelect[int](ctx, tap.SelectOptions[int]{
+		Message: "git message(select):",
+		Options: options,
+	})
+
+	switch {
+	case index == 0 || ctx.Err() != nil:
+		return "", false
+	case index == regenerateIndex:
+		return "", true
+	default:
+		return choices[index-1], false
+	}
+}
+
+// fixMessage asks the llm to correct a message which does not pass the lint
+func fixMessage(ctx context.Context, provider llmclient.Provider, req *llmclient.Request, msg string, issues []*utils.LintIssue) (*llmclient.Response, error) {
+	s := spinner.New(spinner.CharSets[35], 100*time.Millisecond, func(s *spinner.Spinner) {
+		s.Prefix = "fix git message: "
+	})
+	s.Start()
+	defer s.Stop()
+
+	return provider.Generate(ctx, fixRequest(req, msg, issues))
+}
+
+func fixRequest(req *llmclient.Request, msg string, issues []*utils.LintIssue) *llmclient.Request {
+	fixReq := *req
+	fixReq.Messages = append(slices.Clone(req.Messages),
+		llmclient.Message{Role: llmclient.RoleAssistant, Content: msg},
+		llmclient.Message{Role: llmclient.RoleUser, Content: "The commit message has the following issues:\n" + utils.FormatLintIssues(issues) + "\nReply with the corrected commit message only."},
+	)
+	return &fixReq
+}
+
+// summarizeDiff is the map step of the map-reduce mode, every file is summarized on its own
+// and the summaries replace the diff when composing the commit message
+func summarizeDiff(ctx context.Context, provider llmclient.Provider, files []*utils.FileDiff, maxTokens int) (string, llmclient.Usage, error) {
+	s := spinner.New(spinner.CharSets[35], 100*time.Millisecond, func(s *spinner.Spinner) {
+		s.Prefix = fmt.Sprintf("summarize %d files: ", len(files))
+	})
+	s.Start()
+	defer s.Stop()
+
+	var usage llmclient.Usage
+	var summaries = make([]string, len(files))
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(4)
+	for i, file := range files {
+		if file.Binary || utils.IsLowValueFile(file.Path) {
+			summaries[i] = file.Stat()
+			continue
+		}
+
+		g.Go(func() error {
+			resp, err := provider.Generate(ctx, &llmclient.Request{
+				Messages: []llmclient.Message{
+					{Role: llmclient.RoleSystem, Content: utils.GenerateSummaryPrompt()},
+					{Role: llmclient.RoleUser, Content: utils.Ellipse(file.String(), maxTokens)},
+				},
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to summarize %s", file.Path)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			usage = usage.Add(resp.Usage)
+			summaries[i] = fmt.Sprintf("%s: %s", file.Stat(), strings.TrimSpace(resp.Content()))
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return "", usage, err
+	}
+
+	return "The diff is too large, below is a summary of every changed file:\n- " + strings.Join(summaries, "\n- "), usage, nil
+}
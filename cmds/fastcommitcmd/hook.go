@@ -0,0 +1,56 @@
+package fastcommitcmd
+
+import (
+	"context"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+
+	"github.com/pubgo/fastcommit/configs"
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+// PrepareMessage generates the message of the staged changes without any interaction,
+// it is used by the prepare-commit-msg hook, an empty message means nothing is staged
+func PrepareMessage(ctx context.Context, provider llmclient.Provider, cfgs []*Config) (string, error) {
+	var params = cmdParams{Provider: provider, CommitCfg: cfgs}
+
+	diff, err := utils.GetStagedDiff(ctx, utils.NewDiffExcluder(configs.GetRepoPath())).UnwrapErr()
+	if err != nil || diff == nil || len(diff.Files) == 0 {
+		return "", err
+	}
+
+	linter, err := NewLinter(cfgs)
+	if err != nil {
+		return "", errors.WrapCaller(err)
+	}
+
+	withBody := params.body()
+	req, usage, err := params.buildRequest(ctx, diff, linter.Scheme, false, withBody)
+	if err != nil {
+		return "", errors.WrapCaller(err)
+	}
+
+	resp, err := provider.Generate(ctx, req)
+	if err != nil {
+		return "", errors.WrapCaller(err)
+	}
+
+	usage = usage.Add(resp.Usage)
+	msg := resp.Content()
+	if issues := linter.Lint(msg); len(issues) > 0 {
+		if fixed, err := provider.Generate(ctx, fixRequest(req, msg, issues)); err == nil {
+			usage = usage.Add(fixed.Usage)
+			if len(linter.Lint(fixed.Content())) < len(issues) {
+				msg = fixed.Content()
+			}
+		}
+	}
+
+	log.Info().Str("provider", provider.Name()).Str("model", provider.Model()).Any("usage", usage).Msg("llm response usage")
+	if withBody {
+		return utils.ParseCommitMessage(msg).String(), nil
+	}
+	return utils.ParseCommitMessage(msg).Subject + "\n", nil
+}
@@ -2,6 +2,7 @@ package historycmd
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -54,6 +55,11 @@ func New() *redant.Command {
 				set.Add(line)
 			}
 
+			if !utils.IsInteractive() {
+				fmt.Println(strings.Join(set.ToSlice(), "\n"))
+				return nil
+			}
+
 			p := tea.NewProgram(initialModel(set.ToSlice()))
 			_ = lo.Must(p.Run())
 			return nil
@@ -0,0 +1,199 @@
+package hookcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pubgo/dix/v2"
+	"github.com/pubgo/dix/v2/dixcontext"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/pubgo/funk/v2/pathutil"
+	"github.com/pubgo/redant"
+
+	"github.com/pubgo/fastcommit/cmds/fastcommitcmd"
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+const hookName = "prepare-commit-msg"
+
+// hookMarker identifies the hooks written by fastcommit, other hooks are never overwritten or removed
+const hookMarker = "# fastcommit prepare-commit-msg hook"
+
+const hookScript = `#!/bin/sh
+` + hookMarker + `, remove it with: fastcommit hook uninstall
+# the hook never blocks the commit, set FASTCOMMIT_HOOK=0 to skip it
+[ "$FASTCOMMIT_HOOK" = "0" ] && exit 0
+FASTCOMMIT_BIN="${FASTCOMMIT_BIN:-%s}"
+command -v "$FASTCOMMIT_BIN" >/dev/null 2>&1 || FASTCOMMIT_BIN=fastcommit
+"$FASTCOMMIT_BIN" hook run "$@" </dev/null || true
+`
+
+type cmdParams struct {
+	Provider  llmclient.Provider
+	CommitCfg []*fastcommitcmd.Config
+}
+
+func New() *redant.Command {
+	var flags = new(struct {
+		force bool
+	})
+
+	return &redant.Command{
+		Use:   "hook",
+		Short: "manage the git prepare-commit-msg hook which pre-fills the commit message",
+		Children: []*redant.Command{
+			{
+				Use:   "install",
+				Short: "install the prepare-commit-msg hook into .git/hooks or core.hooksPath",
+				Options: []redant.Option{
+					{
+						Flag:        "force",
+						Description: "Replace an existing hook, it is kept as prepare-commit-msg.bak.",
+						Value:       redant.BoolOf(&flags.force),
+					},
+				},
+				Handler: func(ctx context.Context, i *redant.Invocation) error {
+					hookPath, err := getHookPath(ctx)
+					if err != nil {
+						return errors.WrapCaller(err)
+					}
+
+					if data, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(data), hookMarker) {
+						if !flags.force {
+							return errors.Errorf("hook %s already exists, use --force to replace it", hookPath)
+						}
+
+						if err := os.Rename(hookPath, hookPath+".bak"); err != nil {
+							return errors.Wrap(err, "failed to backup the existing hook")
+						}
+						log.Info().Str("path", hookPath+".bak").Msg("existing hook is kept")
+					}
+
+					exe, err := os.Executable()
+					if err != nil {
+						exe = "fastcommit"
+					}
+
+					if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+						return errors.WrapCaller(err)
+					}
+
+					if err := os.WriteFile(hookPath, fmt.Appendf(nil, hookScript, exe), 0o755); err != nil {
+						return errors.Wrapf(err, "failed to write hook %s", hookPath)
+					}
+
+					log.Info().Str("path", hookPath).Msg("hook installed")
+					return nil
+				},
+			},
+			{
+				Use:   "uninstall",
+				Short: "remove the prepare-commit-msg hook installed by fastcommit",
+				Handler: func(ctx context.Context, i *redant.Invocation) error {
+					hookPath, err := getHookPath(ctx)
+					if err != nil {
+						return errors.WrapCaller(err)
+					}
+
+					data, err := os.ReadFile(hookPath)
+					if os.IsNotExist(err) {
+						log.Info().Str("path", hookPath).Msg("hook is not installed")
+						return nil
+					}
+
+					if err != nil {
+						return errors.WrapCaller(err)
+					}
+
+					if !strings.Contains(string(data), hookMarker) {
+						return errors.Errorf("hook %s is not installed by fastcommit", hookPath)
+					}
+
+					if err := os.Remove(hookPath); err != nil {
+						return errors.WrapCaller(err)
+					}
+
+					if pathutil.IsExist(hookPath + ".bak") {
+						if err := os.Rename(hookPath+".bak", hookPath); err != nil {
+							return errors.Wrap(err, "failed to restore the previous hook")
+						}
+						log.Info().Str("path", hookPath).Msg("previous hook restored")
+					}
+
+					log.Info().Str("path", hookPath).Msg("hook uninstalled")
+					return nil
+				},
+			},
+			{
+				Use:   "run",
+				Short: "run by git as prepare-commit-msg hook, args: <msg-file> [source] [sha]",
+				Handler: func(ctx context.Context, i *redant.Invocation) error {
+					if len(i.Args) == 0 {
+						return errors.New("the commit message file is required")
+					}
+
+					// the message is given by -m, -F, a merge, a squash or an amend
+					msgFile := i.Args[0]
+					if len(i.Args) > 1 && i.Args[1] != "" && i.Args[1] != "template" {
+						return nil
+					}
+
+					data, err := os.ReadFile(msgFile)
+					if err != nil {
+						return errors.WrapCaller(err)
+					}
+
+					if hasMessage(string(data)) {
+						return nil
+					}
+
+					di := dixcontext.Get(ctx)
+					var params cmdParams
+					params = dix.Inject(di, params)
+
+					msg, err := fastcommitcmd.PrepareMessage(ctx, params.Provider, params.CommitCfg)
+					if err != nil {
+						log.Err(err).Msg("failed to generate git message, write it yourself")
+						return nil
+					}
+
+					if msg == "" {
+						return nil
+					}
+
+					// keep the comments of git, e.g. the status and the scissors line
+					return errors.WrapCaller(os.WriteFile(msgFile, []byte(msg+string(data)), 0o644))
+				},
+			},
+		},
+	}
+}
+
+// getHookPath resolves the hook in core.hooksPath when set, otherwise in .git/hooks
+func getHookPath(ctx context.Context) (string, error) {
+	output, err := utils.ShellExecOutput(ctx, "git", "rev-parse", "--git-path", "hooks").UnwrapErr()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the git hooks path")
+	}
+
+	hooksPath, err := filepath.Abs(strings.TrimSpace(output))
+	if err != nil {
+		return "", errors.WrapCaller(err)
+	}
+	return filepath.Join(hooksPath, hookName), nil
+}
+
+// hasMessage reports whether the message file has content other than comments, e.g. a template
+func hasMessage(data string) bool {
+	for _, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
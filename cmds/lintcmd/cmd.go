@@ -0,0 +1,94 @@
+package lintcmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/fatih/color"
+	"github.com/pubgo/dix/v2"
+	"github.com/pubgo/dix/v2/dixcontext"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/pubgo/redant"
+
+	"github.com/pubgo/fastcommit/cmds/fastcommitcmd"
+	"github.com/pubgo/fastcommit/utils"
+)
+
+type cmdParams struct {
+	CommitCfg []*fastcommitcmd.Config
+}
+
+func New() *redant.Command {
+	var flags = new(struct {
+		message string
+	})
+
+	return &redant.Command{
+		Use:   "lint",
+		Short: "lint a commit message, args: [file], usable as commit-msg hook: fastcommit lint $1",
+		Long: "The message is read from the file argument, the --message flag, stdin or the last commit.\n" +
+			"Install it as commit-msg hook with: echo 'fastcommit lint \"$1\"' > .git/hooks/commit-msg",
+		Options: []redant.Option{
+			{
+				Flag:        "message",
+				Shorthand:   "m",
+				Description: "Commit message to lint.",
+				Value:       redant.StringOf(&flags.message),
+			},
+		},
+		Handler: func(ctx context.Context, i *redant.Invocation) error {
+			di := dixcontext.Get(ctx)
+			var params cmdParams
+			params = dix.Inject(di, params)
+
+			msg, err := readMessage(i, flags.message)
+			if err != nil {
+				return errors.WrapCaller(err)
+			}
+
+			linter, err := fastcommitcmd.NewLinter(params.CommitCfg)
+			if err != nil {
+				return errors.WrapCaller(err)
+			}
+
+			issues := linter.Lint(msg)
+			if len(issues) == 0 {
+				log.Info().Str("scheme", string(linter.Scheme.Name)).Msg("commit message passes the lint")
+				return nil
+			}
+
+			for _, issue := range issues {
+				fmt.Fprintf(os.Stderr, "%s %s\n", color.RedString("✖ %s:", issue.Rule), issue.Message)
+			}
+			return errors.Errorf("%d issues found in the commit message, scheme: %s", len(issues), linter.Scheme.Name)
+		},
+	}
+}
+
+func readMessage(i *redant.Invocation, message string) (string, error) {
+	if message != "" {
+		return message, nil
+	}
+
+	if len(i.Args) > 0 {
+		data, err := os.ReadFile(i.Args[0])
+		return string(data), errors.WrapCaller(err)
+	}
+
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", errors.WrapCaller(err)
+		}
+
+		if len(data) > 0 {
+			return string(data), nil
+		}
+	}
+
+	return utils.ShellExecOutput(context.Background(), "git", "log", "-1", "--format=%B").UnwrapErr()
+}
@@ -6,14 +6,16 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	semver "github.com/hashicorp/go-version"
+	"github.com/pubgo/dix/v2"
+	"github.com/pubgo/dix/v2/dixcontext"
 	"github.com/pubgo/funk/v2/assert"
 	"github.com/pubgo/funk/v2/errors"
 	"github.com/pubgo/funk/v2/log"
 	"github.com/pubgo/funk/v2/pathutil"
-	"github.com/pubgo/funk/v2/recovery"
 	"github.com/pubgo/funk/v2/result"
 	"github.com/pubgo/redant"
 	"github.com/samber/lo"
@@ -23,9 +25,34 @@ import (
 	"github.com/pubgo/fastcommit/utils/fzfutil"
 )
 
+type cmdParams struct {
+	TagCfg *utils.TagConfig
+}
+
+// tagOutput is printed to stdout by --output json
+type tagOutput struct {
+	Tag        string           `json:"tag"`
+	Channel    string           `json:"channel,omitempty"`
+	Pushed     bool             `json:"pushed"`
+	PushStatus utils.PushStatus `json:"push_status,omitempty"`
+	Bump       string           `json:"bump,omitempty"`
+	Annotated  bool             `json:"annotated"`
+	Signed     bool             `json:"signed"`
+}
+
+// tagListItem is a line of git tag -n
+type tagListItem struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
 func New() *redant.Command {
 	var flags = new(struct {
 		fastCommit bool
+		annotate   bool
+		sign       bool
+		channel    string
+		modules    bool
 	})
 
 	return &redant.Command{
@@ -42,6 +69,22 @@ func New() *redant.Command {
 					})
 
 					var tagText = strings.TrimSpace(utils.ShellExecOutput(ctx, "git", "tag", "-n", "--sort=-committerdate").Unwrap())
+					if utils.IsJSONOutput() {
+						var items = make([]tagListItem, 0)
+						for _, line := range strings.Split(tagText, "\n") {
+							name, msg, _ := strings.Cut(strings.TrimSpace(line), " ")
+							if name != "" {
+								items = append(items, tagListItem{Name: name, Message: strings.TrimSpace(msg)})
+							}
+						}
+						return utils.PrintJSON(items)
+					}
+
+					if !utils.IsInteractive() {
+						fmt.Println(tagText)
+						return nil
+					}
+
 					tag, err := fzfutil.SelectWithFzf(ctx, strings.NewReader(tagText))
 					if err != nil {
 						return err
@@ -51,6 +94,7 @@ func New() *redant.Command {
 					return nil
 				},
 			},
+			newModulesCommand(),
 		},
 		Options: []redant.Option{
 			{
@@ -58,9 +102,38 @@ func New() *redant.Command {
 				Description: "Quickly generate tag.",
 				Value:       redant.BoolOf(&flags.fastCommit),
 			},
+			{
+				Flag:        "channel",
+				Description: "Pre-release channel of the tag, or release, the channel is selected in a list when it is empty.",
+				Value:       redant.StringOf(&flags.channel),
+			},
+			{
+				Flag:        "modules",
+				Description: "Tag the go modules of the repository, e.g. pkg/foo/v1.2.3, the changed modules are selected.",
+				Value:       redant.BoolOf(&flags.modules),
+			},
+			{
+				Flag:        "annotate",
+				Description: "Create an annotated tag with the changelog since the previous tag as message.",
+				Value:       redant.BoolOf(&flags.annotate),
+			},
+			{
+				Flag:        "sign",
+				Shorthand:   "s",
+				Description: "Sign the tag with user.signingkey, gpg.format selects a gpg or ssh key.",
+				Value:       redant.BoolOf(&flags.sign),
+			},
 		},
-		Handler: func(ctx context.Context, i *redant.Invocation) error {
-			defer recovery.Exit()
+		Handler: func(ctx context.Context, i *redant.Invocation) (gErr error) {
+			defer result.RecoveryErr(&gErr)
+
+			var params cmdParams
+			params = dix.Inject(dixcontext.Get(ctx), params)
+
+			// the flags turn the options of the config on
+			var tagCfg = lo.FromPtr(params.TagCfg)
+			tagCfg.Annotate = tagCfg.Annotate || flags.annotate
+			tagCfg.Sign = tagCfg.Sign || flags.sign
 
 			utils.LogConfigAndBranch()
 			if flags.fastCommit {
@@ -73,9 +146,21 @@ func New() *redant.Command {
 						Label: item.Original(),
 					}
 				})
-				selectTags = lo.Chunk(selectTags, 10)[0]
+				selectTags = selectTags[:min(len(selectTags), 10)]
 
-				tagResult := tap.Select[*semver.Version](ctx, tap.SelectOptions[*semver.Version]{
+				// the planned release is the first option
+				plan := getVersionPlan(ctx)
+				if plan.Unchanged() {
+					log.Warn().Str("current", plan.Current.Original()).Msg("no commit since the latest release, no release is planned")
+				} else if !lo.ContainsBy(tags, func(item *semver.Version) bool { return item.Equal(plan.Next) }) {
+					selectTags = append([]tap.SelectOption[*semver.Version]{{
+						Value: plan.Next,
+						Label: plan.Next.Original(),
+						Hint:  fmt.Sprintf("%s bump, %d commits", plan.Bump, len(plan.Commits)),
+					}}, selectTags...)
+				}
+
+				tagResult := utils.PromptSelect[*semver.Version](ctx, tap.SelectOptions[*semver.Version]{
 					Message: "git tag(enter):",
 					Options: selectTags,
 				})
@@ -84,7 +169,7 @@ func New() *redant.Command {
 					return nil
 				}
 
-				tagName := tap.Text(ctx, tap.TextOptions{
+				tagName := utils.PromptText(ctx, tap.TextOptions{
 					Message:      "git tag(enter):",
 					InitialValue: tagResult.Original(),
 					DefaultValue: tagResult.Original(),
@@ -106,64 +191,123 @@ func New() *redant.Command {
 					return fmt.Errorf("tag name is empty")
 				}
 
-				fmt.Println(utils.GitPushTag(ctx, tagName))
-				return nil
+				opts := result.Wrap(utils.GetTagOptions(ctx, &tagCfg, tagName, nil)).Unwrap()
+				res := utils.GitPushTag(ctx, tagName, opts)
+				if utils.IsJSONOutput() {
+					err := utils.PrintJSON(&tagOutput{Tag: tagName, Pushed: !utils.IsDryRun() && res.OK(), PushStatus: res.Status(), Annotated: opts != nil, Signed: tagCfg.Sign})
+					return lo.CoalesceOrEmpty(res.AsError(), err)
+				}
+
+				fmt.Println(res.Output)
+				return res.AsError()
+			}
+
+			channels := tagCfg.GetChannels()
+			for _, c := range channels {
+				if err := c.Validate(); err != nil {
+					return err
+				}
+			}
+
+			names := append(lo.Map(channels, func(item *utils.TagChannel, index int) string { return item.Name }), utils.ReleaseChannel)
+			var selected = flags.channel
+			if selected == "" && !utils.IsInteractive() {
+				return errors.Errorf("--channel is required in non-interactive mode, channels: %s", strings.Join(names, ", "))
+			}
+
+			if selected == "" {
+				options := lo.Map(channels, func(item *utils.TagChannel, index int) tap.SelectOption[string] {
+					return tap.SelectOption[string]{Value: item.Name, Label: item.Name, Hint: item.Description}
+				})
+				selected = utils.PromptSelect[string](ctx, tap.SelectOptions[string]{
+					Message: "pre-release channel:",
+					Options: append(options, tap.SelectOption[string]{Value: utils.ReleaseChannel, Label: utils.ReleaseChannel, Hint: "no pre-release"}),
+				})
 			}
 
-			var p = tea.NewProgram(initialModel())
-			m := assert.Must1(p.Run()).(model)
-			selected := strings.TrimSpace(m.selected)
 			if selected == "" {
 				return nil
 			}
 
+			channel, ok := lo.Find(channels, func(item *utils.TagChannel) bool { return item.Name == selected })
+			if !ok && selected != utils.ReleaseChannel {
+				return errors.Errorf("unknown tag channel %q, channels: %s", selected, strings.Join(names, ", "))
+			}
+
+			if flags.modules {
+				return tagModules(ctx, &tagCfg, channel, selected)
+			}
+
 			tags := utils.GetAllGitTags(ctx)
+			plan := getVersionPlan(ctx)
 
-			var ver *semver.Version
+			// .version pins the core of the tags
+			var core = plan.Next
 			if pathutil.IsExist(".version") {
-				vv := strings.TrimPrefix(string(lo.Must1(os.ReadFile(".version"))), "v")
+				core = lo.Must1(semver.NewSemver(strings.TrimSpace(string(lo.Must1(os.ReadFile(".version"))))))
 				maxTag := lo.MaxBy(tags, func(a *semver.Version, b *semver.Version) bool { return a.Compare(b) > 0 })
-				if maxTag != nil && maxTag.Core().String() != vv {
+				if maxTag != nil && !maxTag.Core().Equal(core.Core()) {
 					log.Warn().Str("max-version", maxTag.Core().String()).Msg("current version is not equal to .version")
 				}
 
-				tags = lo.Filter(tags, func(item *semver.Version, index int) bool { return item.Core().String() == vv })
-				if len(tags) == 0 {
-					ver = lo.Must1(semver.NewSemver(fmt.Sprintf("%s-%s.1", lo.Must1(os.ReadFile(".version")), selected)))
-				} else {
-					ver = utils.GetNextTag(selected, tags)
-				}
-			} else {
-				ver = utils.GetNextTag(selected, tags)
+				tags = lo.Filter(tags, func(item *semver.Version, index int) bool { return item.Core().Equal(core.Core()) })
+			} else if plan.Unchanged() {
+				return errors.Errorf("no commit since the latest release %s, nothing to tag", plan.Current.Original())
 			}
 
-			if selected == envRelease {
-				vv := string(lo.Must1(os.ReadFile(".version")))
-				ver = lo.Must1(semver.NewSemver(vv))
+			var ver = core
+			if channel != nil {
+				ver = channel.NextTag(core, tags, time.Now())
 			}
 
 			tagName := "v" + strings.TrimPrefix(ver.Original(), "v")
-			var p1 = tea.NewProgram(InitialTextInputModel(tagName))
-			m1 := assert.Must1(p1.Run()).(model2)
-			if m1.exit {
-				return nil
+			if utils.IsInteractive() {
+				var p1 = tea.NewProgram(InitialTextInputModel(tagName))
+				m1 := assert.Must1(p1.Run()).(model2)
+				if m1.exit {
+					return nil
+				}
+
+				tagName = m1.Value()
 			}
 
-			tagName = m1.Value()
 			_, err := semver.NewVersion(tagName)
 			if err != nil {
 				return errors.Errorf("tag name is not valid: %s", tagName)
 			}
 
-			output := utils.GitPushTag(ctx, tagName)
-			if utils.IsRemoteTagExist(output) {
+			opts := result.Wrap(utils.GetTagOptions(ctx, &tagCfg, tagName, nil)).Unwrap()
+			res := utils.GitPushTag(ctx, tagName, opts)
+			var pushErr error
+			switch res.Status() {
+			case utils.PushOK, utils.PushUpToDate:
+			case utils.PushTagExists:
+				log.Warn().Str("tag", tagName).Msg("the tag already exists on the remote, fetch the remote tags")
 				utils.Spin("fetch git tag: ", func() (r result.Result[any]) {
 					utils.GitFetchAll(ctx)
 					return
 				})
+			default:
+				pushErr = res.AsError()
 			}
 
-			return nil
+			if utils.IsJSONOutput() {
+				err := utils.PrintJSON(&tagOutput{Tag: tagName, Channel: selected, Pushed: !utils.IsDryRun() && res.OK(), PushStatus: res.Status(), Bump: plan.Bump.String(), Annotated: opts != nil, Signed: tagCfg.Sign})
+				return lo.CoalesceOrEmpty(pushErr, err)
+			}
+			return pushErr
 		},
 	}
 }
+
+// getVersionPlan plans the next release from the conventional commits since the latest release tag
+func getVersionPlan(ctx context.Context) *utils.VersionPlan {
+	plan := result.Wrap(utils.GetVersionPlan(ctx)).Unwrap()
+	log.Info().
+		Str("current", lo.TernaryF(plan.Current != nil, func() string { return plan.Current.Original() }, func() string { return "" })).
+		Str("next", plan.Next.Original()).
+		Str("bump", plan.Bump.String()).
+		Int("commits", len(plan.Commits)).
+		Msg("version plan")
+	return plan
+}
@@ -0,0 +1,153 @@
+package tagcmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/pubgo/funk/v2/result"
+	"github.com/pubgo/redant"
+	"github.com/samber/lo"
+	"github.com/yarlson/tap"
+
+	"github.com/pubgo/fastcommit/utils"
+)
+
+// moduleItem is a go module printed by tag modules --output json
+type moduleItem struct {
+	utils.Module
+	Current string `json:"current,omitempty"`
+	Next    string `json:"next"`
+	Bump    string `json:"bump"`
+	Changed int    `json:"changed"`
+}
+
+func newModulesCommand() *redant.Command {
+	return &redant.Command{
+		Use:   "modules",
+		Short: "list the go modules of the repository with their latest and planned tags",
+		Handler: func(ctx context.Context, i *redant.Invocation) error {
+			plans, err := utils.GetModulePlans(ctx)
+			if err != nil {
+				return err
+			}
+
+			items := lo.Map(plans, func(item *utils.ModulePlan, index int) *moduleItem {
+				m := &moduleItem{
+					Module:  *item.Module,
+					Next:    item.Module.TagName(item.Plan.Next),
+					Bump:    item.Plan.Bump.String(),
+					Changed: item.Changed,
+				}
+				if item.Plan.Current != nil {
+					m.Current = item.Module.TagName(item.Plan.Current)
+				}
+				return m
+			})
+
+			if utils.IsJSONOutput() {
+				return utils.PrintJSON(items)
+			}
+
+			w := tabwriter.NewWriter(utils.Stdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "MODULE\tCURRENT\tNEXT\tCHANGED")
+			for _, item := range items {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d commits\n", item.Name(), lo.CoalesceOrEmpty(item.Current, "-"), item.Next, item.Changed)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// tagModules tags the selected go modules in the channel, the changed modules are selected by default,
+// the modules without a planned release are not offered, all tags are pushed at once
+func tagModules(ctx context.Context, tagCfg *utils.TagConfig, channel *utils.TagChannel, channelName string) error {
+	plans, err := utils.GetModulePlans(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(plans) == 0 {
+		return errors.New("no go module found in the repository")
+	}
+
+	// the modules without commits since their latest release have nothing to tag
+	plans = lo.Filter(plans, func(item *utils.ModulePlan, index int) bool { return !item.Plan.Unchanged() })
+	if len(plans) == 0 {
+		return errors.New("no go module has a commit since its latest release, nothing to tag")
+	}
+
+	changed := lo.Filter(plans, func(item *utils.ModulePlan, index int) bool { return item.Changed > 0 })
+	selected := utils.PromptMultiSelect[*utils.ModulePlan](ctx, tap.MultiSelectOptions[*utils.ModulePlan]{
+		Message: "go modules to tag:",
+		Options: lo.Map(plans, func(item *utils.ModulePlan, index int) tap.SelectOption[*utils.ModulePlan] {
+			current := "-"
+			if item.Plan.Current != nil {
+				current = item.Plan.Current.Original()
+			}
+
+			return tap.SelectOption[*utils.ModulePlan]{
+				Value: item,
+				Label: item.Module.Name(),
+				Hint:  fmt.Sprintf("%s → %s, %d commits", current, item.Plan.Next.Original(), item.Changed),
+			}
+		}),
+		InitialValues: changed,
+	})
+
+	if len(selected) == 0 {
+		log.Info().Msg("no go module is selected, nothing to tag")
+		return nil
+	}
+
+	now := time.Now()
+	var names []string
+	var outputs []*tagOutput
+	for _, plan := range selected {
+		ver := plan.Plan.Next
+		if channel != nil {
+			ver = channel.NextTag(plan.Plan.Next, plan.Module.Tags(ctx), now)
+		}
+
+		name := plan.Module.TagName(ver)
+		names = append(names, name)
+		outputs = append(outputs, &tagOutput{Tag: name, Channel: channelName, Bump: plan.Plan.Bump.String(), Signed: tagCfg.Sign})
+	}
+
+	if !utils.PromptConfirm(ctx, tap.ConfirmOptions{
+		Message:      fmt.Sprintf("create and push the tags %s?", strings.Join(names, ", ")),
+		InitialValue: true,
+	}) {
+		return nil
+	}
+
+	for i, plan := range selected {
+		opts := result.Wrap(utils.GetTagOptions(ctx, tagCfg, names[i], plan.Module)).Unwrap()
+		if err := utils.GitCreateTag(ctx, names[i], opts); err != nil {
+			return errors.Wrapf(err, "failed to create the tag %s", names[i])
+		}
+		outputs[i].Annotated = opts != nil
+	}
+
+	res := utils.GitPush(ctx, append([]string{"origin"}, names...)...)
+	for _, out := range outputs {
+		out.PushStatus = res.Status()
+		if ref, ok := lo.Find(res.Refs, func(item *utils.PushRef) bool { return item.To == "refs/tags/"+out.Tag }); ok {
+			out.PushStatus = ref.Status
+		}
+		out.Pushed = !utils.IsDryRun() && out.PushStatus == utils.PushOK
+	}
+
+	if utils.IsJSONOutput() {
+		return lo.CoalesceOrEmpty(res.AsError(), utils.PrintJSON(outputs))
+	}
+
+	if !utils.IsDryRun() {
+		fmt.Println(res.Output)
+	}
+	return res.AsError()
+}
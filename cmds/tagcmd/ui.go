@@ -8,67 +8,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	semver "github.com/hashicorp/go-version"
-	"github.com/pubgo/funk/v2/log"
 )
 
-const (
-	envAlpha   = "alpha"
-	envBeta    = "beta"
-	envRelease = "release"
-)
-
-type model struct {
-	cursor   int
-	choices  []string
-	selected string
-	length   int
-}
-
-func initialModel() model {
-	choices := []string{envAlpha, envBeta, envRelease}
-	return model{
-		choices: choices,
-		length:  len(choices),
-	}
-}
-
-func (m model) Init() tea.Cmd { return nil }
-
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC:
-			return m, tea.Quit
-		case tea.KeyUp, tea.KeyLeft, tea.KeyDown, tea.KeyRight:
-			m.cursor++
-		case tea.KeyEnter:
-			m.selected = m.choices[m.cursor%m.length]
-			return m, tea.Quit
-		default:
-			log.Error().Str("key", msg.String()).Msg("unknown key")
-			return m, tea.Quit
-		}
-	}
-
-	return m, nil
-}
-
-func (m model) View() string {
-	s := "Please Select Pre Tag:\n"
-
-	for i, choice := range m.choices {
-		cursor := " "
-		if m.cursor%m.length == i {
-			cursor = ">"
-		}
-
-		s += fmt.Sprintf("%s %s\n", cursor, choice)
-	}
-
-	return s
-}
-
 type model1 struct {
 	spinner  spinner.Model
 	quitting bool
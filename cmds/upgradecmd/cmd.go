@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"time"
 
 	"github.com/hashicorp/go-getter"
 	"github.com/hashicorp/go-version"
@@ -21,9 +22,21 @@ import (
 	"github.com/samber/lo"
 	"github.com/yarlson/tap"
 
+	"github.com/pubgo/fastcommit/utils"
 	"github.com/pubgo/fastcommit/utils/githubclient"
 )
 
+// assetItem is a release asset of the current platform, printed to stdout by --output json
+type assetItem struct {
+	Release   string    `json:"release"`
+	Name      string    `json:"name"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	Size      int       `json:"size"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 func New() *redant.Command {
 	return &redant.Command{
 		Use:   "upgrade",
@@ -35,9 +48,7 @@ func New() *redant.Command {
 					client := githubclient.NewPublicRelease("pubgo", "fastcommit")
 					releases := assert.Must1(client.List(ctx))
 
-					tt := tablewriter.NewWriter(os.Stdout)
-					tt.Header([]string{"Name", "Size", "Url"})
-
+					var assets = make([]assetItem, 0)
 					for _, r := range releases {
 						for _, a := range githubclient.GetAssets(r) {
 							if a.IsChecksumFile() {
@@ -52,13 +63,31 @@ func New() *redant.Command {
 								continue
 							}
 
-							assert.Must(tt.Append([]string{
-								a.Name,
-								githubclient.GetSizeFormat(a.Size),
-								a.URL,
-							}))
+							assets = append(assets, assetItem{
+								Release:   r.GetTagName(),
+								Name:      a.Name,
+								OS:        a.OS,
+								Arch:      a.Arch,
+								Size:      a.Size,
+								URL:       a.URL,
+								CreatedAt: a.CreatedAt,
+							})
 						}
 					}
+
+					if utils.IsJSONOutput() {
+						return utils.PrintJSON(assets)
+					}
+
+					tt := tablewriter.NewWriter(os.Stdout)
+					tt.Header([]string{"Name", "Size", "Url"})
+					for _, a := range assets {
+						assert.Must(tt.Append([]string{
+							a.Name,
+							githubclient.GetSizeFormat(a.Size),
+							a.URL,
+						}))
+					}
 					return tt.Render()
 				},
 			},
@@ -87,7 +116,7 @@ func New() *redant.Command {
 				assets = assets[:20]
 			}
 
-			versionName := tap.Select[string](ctx, tap.SelectOptions[string]{
+			versionName := utils.PromptSelect[string](ctx, tap.SelectOptions[string]{
 				Message: "Which version do you prefer?",
 				Options: lo.Map(assets, func(item githubclient.Asset, index int) tap.SelectOption[string] {
 					return tap.SelectOption[string]{
@@ -38,6 +38,21 @@ var GetLocalEnvPath = sync.OnceValue(func() string {
 	return path.Join(GetRepoPath(), ".git", "fastcommit.env")
 })
 
+// GetPromptPath is the user prompt template, it applies to every repository
+var GetPromptPath = sync.OnceValue(func() string {
+	return path.Join(path.Dir(GetConfigPath()), "prompt.tmpl")
+})
+
+// GetRepoPromptPath is the prompt template of the repository, it takes precedence over the user prompt template
+var GetRepoPromptPath = sync.OnceValue(func() string {
+	return path.Join(GetRepoPath(), ".fastcommit", "prompt.tmpl")
+})
+
+// GetRepoSchemePath is the commit scheme of the repository, it takes precedence over the configured commit_type
+var GetRepoSchemePath = sync.OnceValue(func() string {
+	return path.Join(GetRepoPath(), ".fastcommit", "scheme.yaml")
+})
+
 func GetDefaultConfig() []byte { return defaultConfig }
 
 func GetEnvConfig() []byte { return envConfig }
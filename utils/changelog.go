@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/samber/lo"
+)
+
+// ChangelogGroup is a section of the changelog with the commits of its types
+type ChangelogGroup struct {
+	Title string
+	Types []string
+}
+
+// ChangelogGroups mirror changelog.groups of .goreleaser.yaml, the commits of other types are left out
+var ChangelogGroups = []ChangelogGroup{
+	{Title: "New Features", Types: []string{"feat"}},
+	{Title: "Bug Fixes", Types: []string{"fix"}},
+	{Title: "Performance Improvements", Types: []string{"perf"}},
+	{Title: "Refactors", Types: []string{"refactor"}},
+}
+
+// ChangelogSection is a group of the changelog, Breaking Changes lists the breaking commits of all types
+type ChangelogSection struct {
+	Title   string   `json:"title"`
+	Entries []string `json:"entries"`
+}
+
+// Changelog is the release note of the commits between two refs
+type Changelog struct {
+	Version  string              `json:"version"`
+	From     string              `json:"from,omitempty"`
+	To       string              `json:"to"`
+	Date     time.Time           `json:"date"`
+	Summary  string              `json:"summary,omitempty"`
+	Sections []*ChangelogSection `json:"sections"`
+
+	// RepoURL is the web url of the repository, the hashes, issues and PRs are linked when it is set
+	RepoURL string `json:"repo_url,omitempty"`
+}
+
+var (
+	// pullRefRegexp matches the PR number which GitHub appends to squash merged subjects
+	pullRefRegexp  = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+	issueRefRegexp = regexp.MustCompile(`(^|[^\w/\[])#(\d+)\b`)
+)
+
+// refTrailers are the trailers whose #N values are listed after the entry
+var refTrailers = []string{"refs", "closes", "fixes", "resolves"}
+
+// NewChangelog groups the commits by ChangelogGroups, the commits are in the order of git log, newest first
+func NewChangelog(version, from, to, repoURL string, commits []*ConventionalCommit) *Changelog {
+	var c = &Changelog{Version: version, From: from, To: to, Date: time.Now(), RepoURL: repoURL}
+
+	// the entries are listed oldest first
+	commits = slices.Clone(commits)
+	slices.Reverse(commits)
+
+	breaking := lo.Filter(commits, func(item *ConventionalCommit, index int) bool { return item.Breaking })
+	if len(breaking) > 0 {
+		c.Sections = append(c.Sections, &ChangelogSection{Title: "Breaking Changes", Entries: lo.Map(breaking, c.entry)})
+	}
+
+	for _, group := range ChangelogGroups {
+		grouped := lo.Filter(commits, func(item *ConventionalCommit, index int) bool { return slices.Contains(group.Types, item.Type) })
+		if len(grouped) > 0 {
+			c.Sections = append(c.Sections, &ChangelogSection{Title: group.Title, Entries: lo.Map(grouped, c.entry)})
+		}
+	}
+	return c
+}
+
+// entry renders a commit as markdown list item: **scope:** description (#PR) (hash), refs #issue
+func (c *Changelog) entry(commit *ConventionalCommit, _ int) string {
+	desc := commit.Description
+	var pull string
+	if m := pullRefRegexp.FindStringSubmatch(desc); m != nil {
+		desc = strings.TrimSuffix(desc, m[0])
+		pull = m[1]
+	}
+
+	var b strings.Builder
+	if commit.Scope != "" {
+		fmt.Fprintf(&b, "**%s:** ", commit.Scope)
+	}
+	b.WriteString(c.linkIssues(desc))
+
+	if pull != "" {
+		fmt.Fprintf(&b, " (%s)", c.link("#"+pull, "pull/"+pull))
+	}
+
+	if hash := commit.Hash; hash != "" {
+		fmt.Fprintf(&b, " (%s)", c.link(hash[:min(len(hash), 7)], "commit/"+hash))
+	}
+
+	var refs []string
+	for _, t := range commit.Message.Trailers {
+		if !slices.Contains(refTrailers, strings.ToLower(t.Key)) {
+			continue
+		}
+
+		for _, m := range issueRefRegexp.FindAllStringSubmatch(t.Value, -1) {
+			if ref := c.link("#"+m[2], "issues/"+m[2]); !slices.Contains(refs, ref) && m[2] != pull {
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	if len(refs) > 0 {
+		fmt.Fprintf(&b, ", refs %s", strings.Join(refs, ", "))
+	}
+	return b.String()
+}
+
+// linkIssues links the #N references of the text, GitHub redirects the issue url of a PR to the PR
+func (c *Changelog) linkIssues(text string) string {
+	if c.RepoURL == "" {
+		return text
+	}
+
+	return issueRefRegexp.ReplaceAllStringFunc(text, func(s string) string {
+		m := issueRefRegexp.FindStringSubmatch(s)
+		return m[1] + c.link("#"+m[2], "issues/"+m[2])
+	})
+}
+
+func (c *Changelog) link(text, path string) string {
+	if c.RepoURL == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s/%s)", text, c.RepoURL, path)
+}
+
+// Heading is the level 2 heading of the version, linked to the comparison of the refs
+func (c *Changelog) Heading() string {
+	title := c.Version
+	if c.RepoURL != "" && c.From != "" {
+		title = fmt.Sprintf("[%s](%s/compare/%s...%s)", c.Version, c.RepoURL, c.From, lo.Ternary(c.To == "HEAD", c.Version, c.To))
+	}
+	return fmt.Sprintf("## %s (%s)", title, c.Date.Format(time.DateOnly))
+}
+
+// Markdown renders the version section of CHANGELOG.md
+func (c *Changelog) Markdown() string {
+	return c.Heading() + "\n\n" + c.Notes()
+}
+
+// Notes renders the summary and the sections without the heading of the version
+func (c *Changelog) Notes() string {
+	var b strings.Builder
+	if c.Summary != "" {
+		b.WriteString(strings.TrimSpace(c.Summary) + "\n\n")
+	}
+
+	if len(c.Sections) == 0 {
+		b.WriteString("No notable changes.\n\n")
+	}
+
+	for _, s := range c.Sections {
+		b.WriteString("### " + s.Title + "\n\n")
+		for _, entry := range s.Entries {
+			b.WriteString("- " + entry + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+const changelogHeader = "# Changelog\n\n"
+
+// InsertChangelog puts the section of the version above the previous versions of CHANGELOG.md,
+// the section of the same version is replaced, so that the changelog can be regenerated until the release
+func InsertChangelog(data []byte, version, section string) []byte {
+	content := string(data)
+	if strings.TrimSpace(content) == "" {
+		return []byte(changelogHeader + section)
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+
+		if start >= 0 {
+			end = i
+			break
+		}
+
+		if start = i; !isChangelogHeading(line, version) {
+			// a new version, insert it in front of the newest one
+			end = i
+			break
+		}
+	}
+
+	if start < 0 {
+		return []byte(strings.TrimRight(content, "\n") + "\n\n" + section)
+	}
+	return []byte(strings.Join(lines[:start], "") + section + strings.Join(lines[end:], ""))
+}
+
+func isChangelogHeading(line, version string) bool {
+	title := strings.TrimPrefix(strings.TrimPrefix(line, "## "), "[")
+	return strings.HasPrefix(title, version+" ") || strings.HasPrefix(title, version+"]")
+}
+
+// GetConventionalCommits returns the conventional commits of git log revs, newest first, the other commits are skipped
+func GetConventionalCommits(ctx context.Context, revs ...string) ([]*ConventionalCommit, error) {
+	res, err := RunGit(ctx, append([]string{"log", "--format=%H%x00%B%x1e"}, revs...)...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the commits of %s", strings.Join(revs, " "))
+	}
+
+	var commits []*ConventionalCommit
+	for _, entry := range strings.Split(res.Stdout, "\x1e") {
+		hash, msg, ok := strings.Cut(strings.TrimLeft(entry, "\n"), "\x00")
+		if !ok {
+			continue
+		}
+
+		if commit, ok := ParseConventionalCommit(hash, msg); ok {
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// CountCommits returns the number of all commits of the revisions and pathspecs, conventional or not
+func CountCommits(ctx context.Context, revs ...string) (int, error) {
+	res, err := RunGit(ctx, append([]string{"rev-list", "--count"}, revs...)...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to count the commits of %s", strings.Join(revs, " "))
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(res.Stdout))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse the commit count %q", res.Stdout)
+	}
+	return count, nil
+}
+
+// GetRepoURL returns the web url of the origin remote, empty when there is no origin
+func GetRepoURL(ctx context.Context) string {
+	res, err := RunGit(ctx, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return RepoWebURL(strings.TrimSpace(res.Stdout))
+}
+
+// RepoWebURL converts git@host:owner/repo.git, ssh:// and https:// remote urls into https://host/owner/repo
+func RepoWebURL(remote string) string {
+	url := strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	switch {
+	case strings.HasPrefix(url, "git@"):
+		host, path, _ := strings.Cut(strings.TrimPrefix(url, "git@"), ":")
+		return "https://" + host + "/" + path
+	case strings.HasPrefix(url, "ssh://"), strings.HasPrefix(url, "https://"), strings.HasPrefix(url, "http://"):
+		_, rest, _ := strings.Cut(url, "://")
+		if _, hostPath, ok := strings.Cut(rest, "@"); ok {
+			rest = hostPath
+		}
+
+		// the ssh port is not the port of the web server
+		host, path, _ := strings.Cut(rest, "/")
+		if strings.HasPrefix(url, "ssh://") {
+			host, _, _ = strings.Cut(host, ":")
+		}
+		return "https://" + host + "/" + path
+	default:
+		return ""
+	}
+}
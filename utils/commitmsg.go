@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CommitBodyWidth is the width the commit body is wrapped at
+const CommitBodyWidth = 72
+
+// ScissorsLine is written by git commit --verbose and --cleanup=scissors, it and the lines below it are not part of the message
+const ScissorsLine = "# ------------------------ >8 ------------------------"
+
+// Trailer is a git trailer, e.g. "Refs: #123" or "BREAKING CHANGE: drop the v1 api"
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string { return t.Key + ": " + t.Value }
+
+// CommitMessage is a commit message split into subject, body and trailers
+type CommitMessage struct {
+	Subject  string
+	Body     string
+	Trailers []Trailer
+}
+
+var trailerRegexp = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][A-Za-z0-9-]*): (.+)$`)
+
+// ParseTrailer parses a trailer line
+func ParseTrailer(line string) (Trailer, bool) {
+	m := trailerRegexp.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Trailer{}, false
+	}
+	return Trailer{Key: m[1], Value: strings.TrimSpace(m[2])}, true
+}
+
+// ParseCommitMessage splits the message into subject, body and trailers, a code fence around the whole message
+// and the lines from the scissors line on are dropped like git commit --cleanup=scissors does,
+// the trailers are the last paragraph when every line of it is a trailer
+func ParseCommitMessage(msg string) *CommitMessage {
+	var lines []string
+	for _, line := range strings.Split(trimCodeFence(strings.ReplaceAll(msg, "\r\n", "\n")), "\n") {
+		if line == ScissorsLine {
+			break
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+
+	paragraphs := splitParagraphs(lines)
+	if len(paragraphs) == 0 {
+		return new(CommitMessage)
+	}
+
+	var cm = &CommitMessage{Subject: strings.TrimSpace(paragraphs[0][0])}
+	paragraphs[0] = paragraphs[0][1:]
+	if len(paragraphs[0]) == 0 {
+		paragraphs = paragraphs[1:]
+	}
+
+	if n := len(paragraphs); n > 0 {
+		if trailers, ok := parseTrailers(paragraphs[n-1]); ok {
+			cm.Trailers = trailers
+			paragraphs = paragraphs[:n-1]
+		}
+	}
+
+	var body []string
+	for _, p := range paragraphs {
+		body = append(body, strings.Join(p, "\n"))
+	}
+	cm.Body = strings.Join(body, "\n\n")
+	return cm
+}
+
+// trimCodeFence removes the markdown code fence the llm may wrap the whole message in
+func trimCodeFence(msg string) string {
+	trimmed := strings.TrimSpace(msg)
+	first, rest, ok := strings.Cut(trimmed, "\n")
+	if !ok || !strings.HasPrefix(first, "```") || strings.Contains(first[3:], "`") {
+		return msg
+	}
+
+	body, last, ok := cutLast(rest, "\n")
+	if !ok || strings.TrimSpace(last) != "```" {
+		return msg
+	}
+	return body
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// String formats the message for git commit -F, the body is wrapped at CommitBodyWidth
+func (m *CommitMessage) String() string {
+	var parts = []string{m.Subject}
+	if body := WrapText(m.Body, CommitBodyWidth); body != "" {
+		parts = append(parts, body)
+	}
+
+	if len(m.Trailers) > 0 {
+		var trailers []string
+		for _, t := range m.Trailers {
+			trailers = append(trailers, t.String())
+		}
+		parts = append(parts, strings.Join(trailers, "\n"))
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// WrapText wraps every paragraph of the text at width, list items, headings, indented lines and code blocks are kept as they are
+func WrapText(text string, width int) string {
+	var paragraphs []string
+	var fenced bool
+	for _, p := range splitParagraphs(strings.Split(strings.TrimSpace(text), "\n")) {
+		var out []string
+		var words []string
+		flush := func() {
+			if len(words) > 0 {
+				out = append(out, wrapWords(words, width)...)
+				words = nil
+			}
+		}
+
+		for _, line := range p {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				fenced = !fenced
+			}
+
+			if fenced || isVerbatimLine(line) {
+				flush()
+				out = append(out, line)
+				continue
+			}
+			words = append(words, strings.Fields(line)...)
+		}
+		flush()
+		paragraphs = append(paragraphs, strings.Join(out, "\n"))
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func wrapWords(words []string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	for _, word := range words {
+		if line.Len() > 0 && line.Len()+1+len(word) > width {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(word)
+	}
+
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func isVerbatimLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "#") ||
+		strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "```")
+}
+
+func splitParagraphs(lines []string) [][]string {
+	var paragraphs [][]string
+	var cur []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if len(cur) > 0 {
+				paragraphs = append(paragraphs, cur)
+				cur = nil
+			}
+			continue
+		}
+		cur = append(cur, line)
+	}
+
+	if len(cur) > 0 {
+		paragraphs = append(paragraphs, cur)
+	}
+	return paragraphs
+}
+
+func parseTrailers(lines []string) ([]Trailer, bool) {
+	var trailers []Trailer
+	for _, line := range lines {
+		t, ok := ParseTrailer(line)
+		if !ok {
+			return nil, false
+		}
+		trailers = append(trailers, t)
+	}
+	return trailers, true
+}
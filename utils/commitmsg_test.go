@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	msg := ParseCommitMessage("```\nfeat(llm): add streaming\n\nThe provider interface streams the deltas so that the user sees the message while it is generated, which is much faster for long messages.\n\n- openai\n- gemini\n\nRefs: #12\nBREAKING CHANGE: Provider.Generate takes a request\n```")
+	assert.Equal(t, "feat(llm): add streaming", msg.Subject)
+	assert.Len(t, msg.Trailers, 2)
+	assert.Equal(t, Trailer{Key: "Refs", Value: "#12"}, msg.Trailers[0])
+	assert.Equal(t, "BREAKING CHANGE", msg.Trailers[1].Key)
+
+	out := msg.String()
+	for _, line := range strings.Split(out, "\n") {
+		assert.LessOrEqual(t, len(line), CommitBodyWidth, "line is not wrapped: %q", line)
+	}
+	assert.Contains(t, out, "\n\n- openai\n- gemini\n\nRefs: #12\nBREAKING CHANGE:")
+	assert.Equal(t, out, ParseCommitMessage(out).String(), "formatting is not stable")
+
+	msg = ParseCommitMessage("fix: typo")
+	assert.Equal(t, "fix: typo", msg.Subject)
+	assert.Empty(t, msg.Body)
+	assert.Nil(t, msg.Trailers)
+}
+
+func TestParseCommitMessageMarkdown(t *testing.T) {
+	// the headings and code blocks of the body are kept, the help below the scissors line is dropped
+	msg := ParseCommitMessage("docs: explain the config\n\n## Example\n\n```yaml\nsync:\n  strategy: rebase\n```\n\n" + ScissorsLine + "\n# Do not modify or remove the line above.\n")
+	assert.Equal(t, "docs: explain the config", msg.Subject)
+	assert.Equal(t, "## Example\n\n```yaml\nsync:\n  strategy: rebase\n```", msg.Body)
+	assert.Equal(t, "docs: explain the config\n\n"+msg.Body+"\n", msg.String())
+
+	// a fence which does not wrap the whole message is part of it
+	msg = ParseCommitMessage("fix: quote the path\n\n```\nsh -c 'vim \"$1\"'\n```\n\nRefs: #7")
+	assert.Equal(t, "```\nsh -c 'vim \"$1\"'\n```", msg.Body)
+	assert.Equal(t, []Trailer{{Key: "Refs", Value: "#7"}}, msg.Trailers)
+}
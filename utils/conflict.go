@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+// ConflictResolution is how a conflict hunk is resolved
+type ConflictResolution string
+
+const (
+	ResolveNone   ConflictResolution = ""
+	ResolveOurs   ConflictResolution = "ours"
+	ResolveTheirs ConflictResolution = "theirs"
+	ResolveBoth   ConflictResolution = "both"
+	ResolveCustom ConflictResolution = "custom"
+)
+
+// ConflictHunk is a block between the <<<<<<< and >>>>>>> conflict markers, the lines keep their line endings
+type ConflictHunk struct {
+	Ours   []string
+	Theirs []string
+
+	// Base is only set by the diff3 and zdiff3 conflict styles
+	Base      []string
+	BaseLabel string
+
+	// OursLabel and TheirsLabel are the labels of the markers, ours is the upstream when a rebase stopped
+	OursLabel   string
+	TheirsLabel string
+
+	Resolution ConflictResolution
+
+	// Custom are the lines of ResolveCustom, e.g. a suggestion of the llm
+	Custom []string
+}
+
+// Lines are the lines which replace the hunk, nil when it is not resolved
+func (h *ConflictHunk) Lines() []string {
+	switch h.Resolution {
+	case ResolveOurs:
+		return h.Ours
+	case ResolveTheirs:
+		return h.Theirs
+	case ResolveBoth:
+		return append(append([]string{}, h.Ours...), h.Theirs...)
+	case ResolveCustom:
+		return h.Custom
+	default:
+		return nil
+	}
+}
+
+// ConflictFile is a file with conflict markers, split into the text outside the conflicts and the hunks
+type ConflictFile struct {
+	Path  string
+	Hunks []*ConflictHunk
+
+	// chunks are the lines outside the conflicts, chunks[i] is in front of Hunks[i]
+	chunks [][]string
+}
+
+// Resolved reports whether every hunk has a resolution
+func (f *ConflictFile) Resolved() bool {
+	for _, h := range f.Hunks {
+		if h.Resolution == ResolveNone {
+			return false
+		}
+	}
+	return true
+}
+
+// CountResolved returns the number of hunks with a resolution
+func (f *ConflictFile) CountResolved() int {
+	var n int
+	for _, h := range f.Hunks {
+		if h.Resolution != ResolveNone {
+			n++
+		}
+	}
+	return n
+}
+
+// Context returns up to n lines in front of and behind the i-th hunk, the neighbouring hunks are not included
+func (f *ConflictFile) Context(i, n int) (before, after []string) {
+	before = f.chunks[i][max(len(f.chunks[i])-n, 0):]
+	after = f.chunks[i+1][:min(n, len(f.chunks[i+1]))]
+	return before, after
+}
+
+// Bytes renders the file with the resolved hunks, the unresolved ones keep their conflict markers
+func (f *ConflictFile) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, chunk := range f.chunks {
+		buf.WriteString(strings.Join(chunk, ""))
+		if i >= len(f.Hunks) {
+			continue
+		}
+
+		h := f.Hunks[i]
+		if h.Resolution != ResolveNone {
+			buf.WriteString(strings.Join(h.Lines(), ""))
+			continue
+		}
+
+		buf.WriteString(conflictMarkerLine("<<<<<<<", h.OursLabel))
+		buf.WriteString(strings.Join(h.Ours, ""))
+		if h.Base != nil {
+			buf.WriteString(conflictMarkerLine("|||||||", h.BaseLabel))
+			buf.WriteString(strings.Join(h.Base, ""))
+		}
+		buf.WriteString("=======\n")
+		buf.WriteString(strings.Join(h.Theirs, ""))
+		buf.WriteString(conflictMarkerLine(">>>>>>>", h.TheirsLabel))
+	}
+	return buf.Bytes()
+}
+
+// ParseConflictFile splits the content of a conflicted file into hunks, a file without conflict markers has no hunks
+func ParseConflictFile(path string, data []byte) (*ConflictFile, error) {
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+
+	var file = &ConflictFile{Path: path}
+	var chunk []string
+	var hunk *ConflictHunk
+	var state = outside
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+
+		marker, label := parseConflictMarker(line)
+		switch {
+		case state == outside && marker == "<<<<<<<":
+			file.chunks = append(file.chunks, chunk)
+			chunk = nil
+			hunk = &ConflictHunk{OursLabel: label, Ours: []string{}, Theirs: []string{}}
+			state = inOurs
+		case state == inOurs && marker == "|||||||":
+			hunk.Base, hunk.BaseLabel = []string{}, label
+			state = inBase
+		case (state == inOurs || state == inBase) && marker == "=======":
+			state = inTheirs
+		case state == inTheirs && marker == ">>>>>>>":
+			hunk.TheirsLabel = label
+			file.Hunks = append(file.Hunks, hunk)
+			state = outside
+		case state == outside:
+			chunk = append(chunk, line)
+		case state == inOurs:
+			hunk.Ours = append(hunk.Ours, line)
+		case state == inBase:
+			hunk.Base = append(hunk.Base, line)
+		case state == inTheirs:
+			hunk.Theirs = append(hunk.Theirs, line)
+		}
+	}
+
+	if state != outside {
+		return nil, errors.Errorf("unterminated conflict in %s", path)
+	}
+	file.chunks = append(file.chunks, chunk)
+	return file, nil
+}
+
+// parseConflictMarker returns the marker and its label when the line is a conflict marker
+func parseConflictMarker(line string) (string, string) {
+	line = strings.TrimRight(line, "\r\n")
+	for _, marker := range []string{"<<<<<<<", "|||||||", "=======", ">>>>>>>"} {
+		if line == marker || strings.HasPrefix(line, marker+" ") {
+			return marker, strings.TrimSpace(strings.TrimPrefix(line, marker))
+		}
+	}
+	return "", ""
+}
+
+func conflictMarkerLine(marker, label string) string {
+	if label == "" {
+		return marker + "\n"
+	}
+	return marker + " " + label + "\n"
+}
+
+// ConflictCommits returns the subjects of the commits on both sides of the conflict,
+// theirs is the merged, replayed or picked side and ours is HEAD
+func ConflictCommits(ctx context.Context) (ours, theirs []string) {
+	for _, ref := range []string{"MERGE_HEAD", "REBASE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD"} {
+		if _, err := RunGit(ctx, "rev-parse", "--verify", "--quiet", ref); err != nil {
+			continue
+		}
+
+		return commitSubjects(ctx, ref+"..HEAD"), commitSubjects(ctx, "HEAD.."+ref)
+	}
+	return commitSubjects(ctx, "-1", "HEAD"), nil
+}
+
+func commitSubjects(ctx context.Context, args ...string) []string {
+	res, err := RunGit(ctx, append([]string{"log", "-n", "10", "--format=%s"}, args...)...)
+	if err != nil {
+		return nil
+	}
+
+	output := strings.TrimSpace(res.Stdout)
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConflictFile(t *testing.T) {
+	data := "package a\n" +
+		"<<<<<<< HEAD\n" +
+		"const a = 1\n" +
+		"=======\n" +
+		"const a = 2\n" +
+		">>>>>>> feat: change a\n" +
+		"\n" +
+		"<<<<<<< HEAD\n" +
+		"const b = 1\n" +
+		"||||||| base\n" +
+		"const b = 0\n" +
+		"=======\n" +
+		">>>>>>> feat: remove b\n" +
+		"=======\n"
+
+	file, err := ParseConflictFile("a.go", []byte(data))
+	require.NoError(t, err)
+	require.Len(t, file.Hunks, 2)
+	assert.False(t, file.Resolved())
+
+	h := file.Hunks[0]
+	assert.Equal(t, "HEAD", h.OursLabel)
+	assert.Equal(t, "feat: change a", h.TheirsLabel)
+	assert.Nil(t, h.Base)
+
+	h = file.Hunks[1]
+	assert.Len(t, h.Base, 1)
+	assert.Empty(t, h.Theirs)
+
+	before, after := file.Context(1, 1)
+	assert.Equal(t, []string{"\n"}, before)
+	assert.Equal(t, []string{"=======\n"}, after)
+
+	// nothing is resolved, the file is rendered as it was
+	assert.Equal(t, data, string(file.Bytes()))
+
+	file.Hunks[0].Resolution = ResolveBoth
+	assert.True(t, strings.HasPrefix(string(file.Bytes()), "package a\nconst a = 1\nconst a = 2\n\n<<<<<<< HEAD\n"), "partly resolved = %q", file.Bytes())
+
+	file.Hunks[1].Resolution, file.Hunks[1].Custom = ResolveCustom, []string{"const b = 2\n"}
+	assert.Equal(t, "package a\nconst a = 1\nconst a = 2\n\nconst b = 2\n=======\n", string(file.Bytes()))
+	assert.True(t, file.Resolved())
+
+	_, err = ParseConflictFile("b.go", []byte("<<<<<<< HEAD\na\n"))
+	assert.Error(t, err, "an unterminated conflict")
+}
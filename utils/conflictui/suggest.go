@@ -0,0 +1,129 @@
+package conflictui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/samber/lo"
+	"github.com/yarlson/tap"
+
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+// contextLines is the number of lines around a hunk sent to the llm
+const contextLines = 20
+
+const suggestPrompt = `You resolve git merge conflicts.
+You get one conflict hunk of a file: the lines of both sides, the lines of the common base when git has them,
+the lines around the conflict and the commits of both sides.
+Combine the intent of both sides, keep both changes when they are compatible and keep the indentation of the file.
+Reply with the lines which replace the whole hunk only, without conflict markers, explanations or code fences.`
+
+// Suggester asks the llm for the resolution of conflict hunks
+type Suggester struct {
+	Provider llmclient.Provider
+
+	// Ours and Theirs are the commit subjects of both sides
+	Ours   []string
+	Theirs []string
+}
+
+// OfferSuggestions asks whether the llm should suggest resolutions, it is nil when there is no provider or the offer is declined
+func OfferSuggestions(ctx context.Context, provider llmclient.Provider) *Suggester {
+	if provider == nil || !utils.IsInteractive() {
+		return nil
+	}
+
+	if !utils.PromptConfirm(ctx, tap.ConfirmOptions{
+		Message:      fmt.Sprintf("Ask %s for conflict resolutions?", provider.Name()),
+		InitialValue: true,
+	}) {
+		return nil
+	}
+
+	ours, theirs := utils.ConflictCommits(ctx)
+	return &Suggester{Provider: provider, Ours: ours, Theirs: theirs}
+}
+
+// Suggest returns the proposed lines of the i-th hunk of the file
+func (s *Suggester) Suggest(ctx context.Context, file *utils.ConflictFile, i int) ([]string, error) {
+	resp, err := s.Provider.Generate(ctx, &llmclient.Request{
+		Messages: []llmclient.Message{
+			{Role: llmclient.RoleSystem, Content: suggestPrompt},
+			{Role: llmclient.RoleUser, Content: s.prompt(file, i)},
+		},
+		Temperature: lo.ToPtr(float32(0.2)),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to suggest a resolution of %s", file.Path)
+	}
+
+	log.Info().Str("file", file.Path).Any("usage", resp.Usage).Msg("conflict resolution suggested")
+	return parseSuggestion(resp.Content()), nil
+}
+
+func (s *Suggester) prompt(file *utils.ConflictFile, i int) string {
+	hunk := file.Hunks[i]
+	before, after := file.Context(i, contextLines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "File: %s\n\n", file.Path)
+	writeList(&b, "Commits of ours ("+hunk.OursLabel+")", s.Ours)
+	writeList(&b, "Commits of theirs ("+hunk.TheirsLabel+")", s.Theirs)
+	writeBlock(&b, "Lines before the conflict", before)
+	writeBlock(&b, "Ours", hunk.Ours)
+	if hunk.Base != nil {
+		writeBlock(&b, "Base", hunk.Base)
+	}
+	writeBlock(&b, "Theirs", hunk.Theirs)
+	writeBlock(&b, "Lines after the conflict", after)
+	return b.String()
+}
+
+func writeList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+
+	b.WriteString(title + ":\n")
+	for _, item := range items {
+		b.WriteString("- " + item + "\n")
+	}
+	b.WriteString("\n")
+}
+
+func writeBlock(b *strings.Builder, title string, lines []string) {
+	b.WriteString(title + ":\n```\n")
+	content := strings.Join(lines, "")
+	b.WriteString(content)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("```\n\n")
+}
+
+// parseSuggestion splits the reply into lines, the code fence around it is dropped
+func parseSuggestion(content string) []string {
+	content = strings.Trim(content, "\r\n")
+	if content == "" {
+		return []string{}
+	}
+
+	lines := strings.Split(content, "\n")
+	if strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		lines = lines[1:]
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" {
+			lines = lines[:n-1]
+		}
+	}
+
+	var result = make([]string, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, strings.TrimRight(line, "\r")+"\n")
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package conflictui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+type fakeProvider struct {
+	reply string
+	req   *llmclient.Request
+}
+
+func (p *fakeProvider) Name() string  { return "fake" }
+func (p *fakeProvider) Model() string { return "fake" }
+
+func (p *fakeProvider) Generate(ctx context.Context, req *llmclient.Request) (*llmclient.Response, error) {
+	p.req = req
+	return &llmclient.Response{Choices: []string{p.reply}}, nil
+}
+
+func (p *fakeProvider) Stream(ctx context.Context, req *llmclient.Request, onDelta func(delta string)) (*llmclient.Response, error) {
+	return p.Generate(ctx, req)
+}
+
+func (p *fakeProvider) CountTokens(ctx context.Context, msgs ...llmclient.Message) (int, error) {
+	return 0, nil
+}
+
+func TestSuggest(t *testing.T) {
+	file, err := utils.ParseConflictFile("a.go", []byte("func a() {\n<<<<<<< HEAD\n\treturn 1\n=======\n\treturn 2\n>>>>>>> feat: b\n}\n"))
+	require.NoError(t, err)
+
+	provider := &fakeProvider{reply: "```go\n\t// keep both\n\treturn 1 + 2\n```\n"}
+	s := &Suggester{Provider: provider, Ours: []string{"fix: return 1"}, Theirs: []string{"feat: return 2"}}
+	lines, err := s.Suggest(context.Background(), file, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"\t// keep both\n", "\treturn 1 + 2\n"}, lines)
+
+	prompt := provider.req.Messages[1].Content
+	for _, want := range []string{"File: a.go", "- fix: return 1", "- feat: return 2", "Ours:\n```\n\treturn 1\n```", "Lines after the conflict:\n```\n}\n```"} {
+		assert.Contains(t, prompt, want)
+	}
+
+	assert.Empty(t, parseSuggestion("\n"))
+}
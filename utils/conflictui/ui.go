@@ -0,0 +1,371 @@
+package conflictui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/samber/lo"
+
+	"github.com/pubgo/fastcommit/utils"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	resolvedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	pendingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	activeStyle   = paneStyle.BorderForeground(lipgloss.Color("205"))
+)
+
+// Run shows the unmerged files of the repo root, every resolved file is written and staged,
+// suggester is optional and proposes resolutions, it reports whether every file was resolved
+func Run(ctx context.Context, root string, files []string, editor string, suggester *Suggester) (bool, error) {
+	var m = &model{
+		ctx:         ctx,
+		root:        root,
+		editor:      editor,
+		suggester:   suggester,
+		suggestions: make(map[*utils.ConflictHunk]*suggestion),
+		width:       100,
+		hunk:        -1,
+	}
+	for _, path := range files {
+		file, err := m.load(path)
+		if err != nil {
+			return false, err
+		}
+		m.files = append(m.files, file)
+	}
+
+	if m.allResolved() {
+		return true, nil
+	}
+
+	res, err := tea.NewProgram(m, tea.WithContext(ctx), tea.WithAltScreen()).Run()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to run the conflict view")
+	}
+
+	m = res.(*model)
+	if m.err != nil {
+		return false, m.err
+	}
+	return m.allResolved(), nil
+}
+
+type editedMsg struct{ err error }
+
+type suggestedMsg struct {
+	hunk  *utils.ConflictHunk
+	lines []string
+	err   error
+}
+
+// suggestion is the proposed resolution of a hunk, lines is nil while the llm is asked
+type suggestion struct {
+	lines []string
+	err   error
+}
+
+type model struct {
+	ctx    context.Context
+	root   string
+	editor string
+	files  []*utils.ConflictFile
+
+	suggester   *Suggester
+	suggestions map[*utils.ConflictHunk]*suggestion
+
+	// file is the index of the selected file, hunk is the index of the shown hunk, -1 shows the file list
+	file int
+	hunk int
+
+	width int
+	err   error
+}
+
+func (m *model) Init() tea.Cmd { return nil }
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case editedMsg:
+		if msg.err != nil {
+			log.Err(msg.err).Str("file", m.current().Path).Msg("failed to run the editor")
+		}
+
+		file, err := m.load(m.current().Path)
+		if err != nil {
+			m.err = err
+			return m, tea.Quit
+		}
+
+		// the hunks changed, start again from the file list
+		m.files[m.file], m.hunk = file, -1
+		return m, m.afterResolve()
+	case suggestedMsg:
+		m.suggestions[msg.hunk] = &suggestion{lines: msg.lines, err: msg.err}
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC || msg.String() == "q" {
+			return m, tea.Quit
+		}
+
+		if m.hunk < 0 {
+			return m, tea.Batch(m.updateList(msg), m.suggest())
+		}
+		return m, tea.Batch(m.updateHunk(msg), m.suggest())
+	}
+	return m, nil
+}
+
+func (m *model) updateList(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		m.file = (m.file - 1 + len(m.files)) % len(m.files)
+	case "down", "j", "tab":
+		m.file = (m.file + 1) % len(m.files)
+	case "e":
+		return m.edit()
+	case "enter", "right", "l":
+		if len(m.current().Hunks) == 0 {
+			return m.edit()
+		}
+		m.hunk = m.nextUnresolved(0)
+	}
+	return nil
+}
+
+func (m *model) updateHunk(msg tea.KeyMsg) tea.Cmd {
+	file := m.current()
+	switch msg.String() {
+	case "esc", "backspace":
+		m.hunk = -1
+	case "left", "p", "h":
+		m.hunk = max(m.hunk-1, 0)
+	case "right", "n", "l":
+		m.hunk = min(m.hunk+1, len(file.Hunks)-1)
+	case "o":
+		return m.resolve(utils.ResolveOurs)
+	case "t":
+		return m.resolve(utils.ResolveTheirs)
+	case "b":
+		return m.resolve(utils.ResolveBoth)
+	case "u":
+		file.Hunks[m.hunk].Resolution = utils.ResolveNone
+	case "e":
+		return m.edit()
+	case "a":
+		// ask again
+		delete(m.suggestions, file.Hunks[m.hunk])
+	case "y", "m":
+		hunk := file.Hunks[m.hunk]
+		s := m.suggestions[hunk]
+		if s == nil || s.lines == nil {
+			return nil
+		}
+
+		hunk.Custom = s.lines
+		if msg.String() == "m" {
+			// the suggestion is written to the file and edited there
+			hunk.Resolution = utils.ResolveCustom
+			return m.edit()
+		}
+		return m.resolve(utils.ResolveCustom)
+	case "r":
+		if s := m.suggestions[file.Hunks[m.hunk]]; s != nil && s.lines != nil {
+			s.lines, s.err = nil, errors.New("rejected")
+		}
+	}
+	return nil
+}
+
+// suggest asks the llm for the resolution of the shown hunk once
+func (m *model) suggest() tea.Cmd {
+	if m.suggester == nil || m.hunk < 0 {
+		return nil
+	}
+
+	file, i := m.current(), m.hunk
+	hunk := file.Hunks[i]
+	if _, ok := m.suggestions[hunk]; ok || hunk.Resolution != utils.ResolveNone {
+		return nil
+	}
+
+	m.suggestions[hunk] = new(suggestion)
+	return func() tea.Msg {
+		lines, err := m.suggester.Suggest(m.ctx, file, i)
+		return suggestedMsg{hunk: hunk, lines: lines, err: err}
+	}
+}
+
+func (m *model) resolve(resolution utils.ConflictResolution) tea.Cmd {
+	m.current().Hunks[m.hunk].Resolution = resolution
+	if !m.current().Resolved() {
+		m.hunk = m.nextUnresolved(m.hunk + 1)
+		return nil
+	}
+
+	if err := m.write(m.current()); err != nil {
+		m.err = err
+		return tea.Quit
+	}
+	return m.afterResolve()
+}
+
+// afterResolve stages the file when it is resolved, and quits when every file is resolved
+func (m *model) afterResolve() tea.Cmd {
+	file := m.current()
+	if !file.Resolved() {
+		return nil
+	}
+
+	if _, err := utils.RunGit(m.ctx, "-C", m.root, "add", "-A", "--", file.Path); err != nil {
+		m.err = errors.Wrapf(err, "failed to stage %s", file.Path)
+		return tea.Quit
+	}
+
+	m.hunk = -1
+	if m.allResolved() {
+		return tea.Quit
+	}
+
+	for i := range m.files {
+		if next := (m.file + 1 + i) % len(m.files); !m.files[next].Resolved() {
+			m.file = next
+			break
+		}
+	}
+	return nil
+}
+
+// edit writes the resolutions so far and opens the file in the editor
+func (m *model) edit() tea.Cmd {
+	file := m.current()
+	if err := m.write(file); err != nil {
+		m.err = err
+		return tea.Quit
+	}
+
+	cmd := exec.Command("sh", "-c", m.editor+` "$0"`, filepath.Join(m.root, file.Path))
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return editedMsg{err: err} })
+}
+
+func (m *model) load(path string) (*utils.ConflictFile, error) {
+	data, err := os.ReadFile(filepath.Join(m.root, path))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.WrapCaller(err)
+	}
+	return utils.ParseConflictFile(path, data)
+}
+
+func (m *model) write(file *utils.ConflictFile) error {
+	if len(file.Hunks) == 0 {
+		return nil
+	}
+
+	return errors.WrapCaller(os.WriteFile(filepath.Join(m.root, file.Path), file.Bytes(), 0o644))
+}
+
+func (m *model) current() *utils.ConflictFile { return m.files[m.file] }
+
+func (m *model) nextUnresolved(from int) int {
+	hunks := m.current().Hunks
+	for i := range hunks {
+		if idx := (from + i) % len(hunks); hunks[idx].Resolution == utils.ResolveNone {
+			return idx
+		}
+	}
+	return min(from, len(hunks)-1)
+}
+
+func (m *model) allResolved() bool {
+	for _, file := range m.files {
+		if !file.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *model) View() string {
+	if m.hunk < 0 {
+		return m.listView()
+	}
+	return m.hunkView()
+}
+
+func (m *model) listView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Unmerged files") + "\n\n")
+	for i, file := range m.files {
+		cursor := " "
+		if i == m.file {
+			cursor = ">"
+		}
+
+		state := pendingStyle.Render(fmt.Sprintf("%d/%d", file.CountResolved(), len(file.Hunks)))
+		if file.Resolved() {
+			state = resolvedStyle.Render("resolved")
+		}
+		fmt.Fprintf(&b, "%s %s  %s\n", cursor, file.Path, state)
+	}
+	b.WriteString("\n" + helpStyle.Render("↑/↓ select • enter resolve hunks • e edit • q quit"))
+	return b.String()
+}
+
+func (m *model) hunkView() string {
+	file := m.current()
+	hunk := file.Hunks[m.hunk]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  conflict %d/%d", titleStyle.Render(file.Path), m.hunk+1, len(file.Hunks))
+	if hunk.Resolution != utils.ResolveNone {
+		b.WriteString("  " + resolvedStyle.Render("→ "+string(hunk.Resolution)))
+	}
+	b.WriteString("\n\n")
+
+	// the borders and the padding take 4 columns of every pane
+	width := max(m.width/2-4, 20)
+	ours := lo.Ternary(hunk.Resolution == utils.ResolveOurs || hunk.Resolution == utils.ResolveBoth, activeStyle, paneStyle)
+	theirs := lo.Ternary(hunk.Resolution == utils.ResolveTheirs || hunk.Resolution == utils.ResolveBoth, activeStyle, paneStyle)
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+		ours.Width(width).Render(pane("ours: "+hunk.OursLabel, hunk.Ours)),
+		theirs.Width(width).Render(pane("theirs: "+hunk.TheirsLabel, hunk.Theirs)),
+	))
+	b.WriteString("\n")
+
+	help := "o ours • t theirs • b both • u undo • e edit • ←/→ hunk • esc files • q quit"
+	if s := m.suggestions[hunk]; s != nil {
+		switch {
+		case s.err != nil:
+			b.WriteString(pendingStyle.Render("suggestion: "+s.err.Error()) + "\n")
+			help = "a ask again • " + help
+		case s.lines == nil:
+			b.WriteString(helpStyle.Render("asking "+m.suggester.Provider.Name()+" for a resolution...") + "\n")
+		default:
+			style := lo.Ternary(hunk.Resolution == utils.ResolveCustom, activeStyle, paneStyle)
+			b.WriteString(style.Width(2*width+2).Render(pane("suggestion of "+m.suggester.Provider.Name(), s.lines)) + "\n")
+			help = "y accept • m edit • r reject • a ask again • " + help
+		}
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
+
+func pane(title string, lines []string) string {
+	content := strings.TrimRight(strings.Join(lines, ""), "\r\n")
+	if content == "" {
+		content = helpStyle.Render("(empty)")
+	}
+	return titleStyle.Render(title) + "\n" + content
+}
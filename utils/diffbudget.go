@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FileDiff is the diff of a single file split into its header and hunks
+type FileDiff struct {
+	Path    string
+	Header  string
+	Hunks   []string
+	Added   int
+	Removed int
+	Binary  bool
+}
+
+func (f *FileDiff) String() string {
+	return f.Header + strings.Join(f.Hunks, "")
+}
+
+// Stat returns the one line summary of the file diff
+func (f *FileDiff) Stat() string {
+	if f.Binary {
+		return fmt.Sprintf("%s (binary)", f.Path)
+	}
+	return fmt.Sprintf("%s (+%d -%d)", f.Path, f.Added, f.Removed)
+}
+
+// ParseDiff splits the output of git diff into file diffs
+func ParseDiff(diff string) []*FileDiff {
+	var files []*FileDiff
+	var cur *FileDiff
+	var header, hunk strings.Builder
+
+	flushHunk := func() {
+		if cur != nil && hunk.Len() > 0 {
+			cur.Hunks = append(cur.Hunks, hunk.String())
+			hunk.Reset()
+		}
+	}
+
+	flushFile := func() {
+		if cur == nil {
+			return
+		}
+		flushHunk()
+		cur.Header = header.String()
+		header.Reset()
+		files = append(files, cur)
+	}
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushFile()
+			cur = &FileDiff{Path: diffPathFromHeader(strings.TrimSpace(line))}
+			header.WriteString(line)
+		case cur == nil:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			hunk.WriteString(line)
+		case len(cur.Hunks) == 0 && hunk.Len() == 0:
+			if p, ok := strings.CutPrefix(strings.TrimSpace(line), "+++ b/"); ok {
+				cur.Path = p
+			}
+			if strings.HasPrefix(line, "Binary files ") {
+				cur.Binary = true
+			}
+			header.WriteString(line)
+		default:
+			if strings.HasPrefix(line, "+") {
+				cur.Added++
+			} else if strings.HasPrefix(line, "-") {
+				cur.Removed++
+			}
+			hunk.WriteString(line)
+		}
+	}
+	flushFile()
+
+	return files
+}
+
+func diffPathFromHeader(line string) string {
+	if _, p, ok := strings.Cut(line, " b/"); ok {
+		return p
+	}
+	return strings.TrimPrefix(line, "diff --git ")
+}
+
+// DiffPriority ranks the files of a diff, the lower the priority the earlier the file is cut from the prompt
+func DiffPriority(filePath string) int {
+	name := path.Base(filePath)
+	switch {
+	case IsLowValueFile(filePath):
+		return 0
+	case strings.HasSuffix(name, ".md") || strings.HasPrefix(filePath, "docs/"):
+		return 1
+	case strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".spec.") || strings.Contains(name, ".test."):
+		return 2
+	default:
+		return 3
+	}
+}
+
+var lowValueSuffixes = []string{".sum", ".lock", "-lock.json", "-lock.yaml", ".pb.go", "_gen.go", ".gen.go", ".min.js", ".min.css", ".map", ".svg"}
+
+// IsLowValueFile reports whether the file is a lock, generated or minified file
+func IsLowValueFile(filePath string) bool {
+	if strings.HasPrefix(filePath, "vendor/") || strings.Contains(filePath, "/vendor/") {
+		return true
+	}
+
+	for _, suffix := range lowValueSuffixes {
+		if strings.HasSuffix(filePath, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type DiffBudget struct {
+	// Diff is the diff which fits into the token budget
+	Diff   string
+	Tokens int
+
+	// Truncated are the files of which only the leading hunks are kept
+	Truncated []string
+
+	// Omitted are the files of which only the stat line is kept
+	Omitted []string
+}
+
+// BudgetDiff fits the diff into maxTokens, files are kept by priority, and within a file hunks are kept in order,
+// every file which does not fit at all is still mentioned by its stat line, nil count estimates the tokens by CountTokens
+func BudgetDiff(diff string, maxTokens int, count TokenCounter) *DiffBudget {
+	if count == nil {
+		count = CountTokens
+	}
+
+	tokens := count(diff)
+	if maxTokens <= 0 || tokens <= maxTokens {
+		return &DiffBudget{Diff: diff, Tokens: tokens}
+	}
+
+	files := ParseDiff(diff)
+	order := make([]*FileDiff, len(files))
+	copy(order, files)
+	sort.SliceStable(order, func(i, j int) bool {
+		pi, pj := DiffPriority(order[i].Path), DiffPriority(order[j].Path)
+		if pi != pj {
+			return pi > pj
+		}
+		return len(order[i].String()) < len(order[j].String())
+	})
+
+	// every file keeps at least its stat line, so reserve them first
+	remaining := maxTokens
+	for _, f := range files {
+		remaining -= count(f.Stat()) + 1
+	}
+
+	var budget = new(DiffBudget)
+	var kept = make(map[*FileDiff]string, len(files))
+	for _, f := range order {
+		if f.Binary {
+			continue
+		}
+
+		full := f.String()
+		if n := count(full); n <= remaining {
+			kept[f] = full
+			remaining -= n
+			continue
+		}
+
+		var text strings.Builder
+		var used = count(f.Header)
+		var hunks int
+		for _, hunk := range f.Hunks {
+			n := count(hunk)
+			if used+n > remaining {
+				break
+			}
+			used += n
+			hunks++
+			text.WriteString(hunk)
+		}
+
+		if hunks == 0 {
+			continue
+		}
+
+		kept[f] = fmt.Sprintf("%s%s# %d more hunks omitted\n", f.Header, text.String(), len(f.Hunks)-hunks)
+		remaining -= used
+		budget.Truncated = append(budget.Truncated, f.Path)
+	}
+
+	var out strings.Builder
+	var omitted []string
+	for _, f := range files {
+		if text, ok := kept[f]; ok {
+			out.WriteString(text)
+			continue
+		}
+
+		omitted = append(omitted, f.Stat())
+		if !f.Binary {
+			budget.Omitted = append(budget.Omitted, f.Path)
+		}
+	}
+
+	if len(omitted) > 0 {
+		out.WriteString("# diff omitted to fit the token budget:\n")
+		for _, stat := range omitted {
+			out.WriteString("# " + stat + "\n")
+		}
+	}
+
+	budget.Diff = strings.TrimSpace(out.String())
+	budget.Tokens = count(budget.Diff)
+	return budget
+}
@@ -0,0 +1,90 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pubgo/fastcommit/utils"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++import "fmt"
+@@ -10,2 +11,3 @@ func main() {
+-	println("hello")
++	fmt.Println("hello")
+diff --git a/go.sum b/go.sum
+index 3333333..4444444 100644
+--- a/go.sum
++++ b/go.sum
+@@ -1,1 +1,2 @@
++github.com/a/b v1.0.0 h1:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa=
++github.com/a/b v1.0.0/go.mod h1:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb=
+diff --git a/logo.png b/logo.png
+index 5555555..6666666 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	files := utils.ParseDiff(testDiff)
+	assert.Len(t, files, 3)
+
+	assert.Equal(t, "main.go", files[0].Path)
+	assert.Len(t, files[0].Hunks, 2)
+	assert.Equal(t, 2, files[0].Added)
+	assert.Equal(t, 1, files[0].Removed)
+
+	assert.Equal(t, "go.sum", files[1].Path)
+	assert.Equal(t, "logo.png", files[2].Path)
+	assert.True(t, files[2].Binary)
+	assert.Equal(t, testDiff, files[0].String()+files[1].String()+files[2].String())
+}
+
+func TestBudgetDiff(t *testing.T) {
+	budget := utils.BudgetDiff(testDiff, 0, nil)
+	assert.Equal(t, testDiff, budget.Diff)
+
+	budget = utils.BudgetDiff(testDiff, utils.CountTokens(testDiff), nil)
+	assert.Equal(t, testDiff, budget.Diff)
+
+	// the lock file is dropped first, the source file is kept
+	budget = utils.BudgetDiff(testDiff, 140, nil)
+	assert.LessOrEqual(t, budget.Tokens, 140)
+	assert.Contains(t, budget.Diff, `fmt.Println("hello")`)
+	assert.Equal(t, []string{"go.sum"}, budget.Omitted)
+	assert.Contains(t, budget.Diff, "# go.sum (+2 -0)")
+	assert.Contains(t, budget.Diff, "# logo.png (binary)")
+
+	// only the first hunk of the source file fits
+	budget = utils.BudgetDiff(testDiff, 100, nil)
+	assert.Equal(t, []string{"main.go"}, budget.Truncated)
+	assert.Contains(t, budget.Diff, "# 1 more hunks omitted")
+	assert.NotContains(t, budget.Diff, `fmt.Println("hello")`)
+}
+
+func TestEllipse(t *testing.T) {
+	assert.Equal(t, "hello world", utils.Ellipse("hello world", 10))
+	assert.Equal(t, "", utils.Ellipse("hello world", 0))
+
+	long := strings.Repeat("token ", 100)
+	assert.LessOrEqual(t, utils.CountTokens(utils.Ellipse(long, 10)), 10+3)
+}
+
+func TestScaledTokenCounter(t *testing.T) {
+	count := utils.ScaledTokenCounter(testDiff, 2*utils.CountTokens(testDiff))
+	assert.Equal(t, 2*utils.CountTokens(testDiff), count(testDiff))
+	assert.Equal(t, 2*utils.CountTokens("hello world"), count("hello world"))
+
+	// the budget is measured by the counter of the provider
+	budget := utils.BudgetDiff(testDiff, utils.CountTokens(testDiff), count)
+	assert.NotEqual(t, testDiff, budget.Diff)
+	assert.LessOrEqual(t, budget.Tokens, utils.CountTokens(testDiff))
+
+	assert.Equal(t, utils.CountTokens("hello"), utils.ScaledTokenCounter(testDiff, 0)("hello"))
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var dryRun bool
+
+// SetDryRun enables the dry-run mode, in which git commands that change the repository or a remote are printed instead of run
+func SetDryRun(v bool) { dryRun = v }
+
+// IsDryRun reports whether mutating git commands are only printed
+func IsDryRun() bool { return dryRun }
+
+// mutatingGitCommands change the work tree, the index, the refs or a remote,
+// fetch is not one of them, so that a dry run still sees the state of the remote
+var mutatingGitCommands = []string{
+	"add", "am", "apply", "checkout", "cherry-pick", "clean", "commit", "merge", "mv", "pull", "push",
+	"rebase", "reset", "restore", "revert", "rm", "stash", "switch", "update-ref",
+}
+
+// IsMutatingGit reports whether the command line is a git command which changes the repository or a remote
+func IsMutatingGit(args []string) bool {
+	if len(args) == 0 || args[0] != "git" {
+		return false
+	}
+
+	sub, rest := gitSubcommand(args[1:])
+	switch sub {
+	case "":
+		return false
+	case "branch":
+		return slices.ContainsFunc(rest, func(arg string) bool {
+			return slices.Contains([]string{"-d", "-D", "--delete", "-m", "-M", "--move", "-c", "-C", "--copy", "-u", "--unset-upstream"}, arg) ||
+				strings.HasPrefix(arg, "--set-upstream-to")
+		}) || (hasPositional(rest) && !slices.ContainsFunc(rest, isListFlag))
+	case "tag":
+		return slices.ContainsFunc(rest, func(arg string) bool {
+			return slices.Contains([]string{"-d", "--delete", "-a", "--annotate", "-s", "--sign", "-m", "-F", "-f", "--force"}, arg)
+		}) || (hasPositional(rest) && !slices.ContainsFunc(rest, isListFlag))
+	case "worktree":
+		return len(rest) > 0 && slices.Contains([]string{"add", "remove", "move", "prune"}, rest[0])
+	default:
+		return slices.Contains(mutatingGitCommands, sub)
+	}
+}
+
+// RecordDryRun prints the command line, and the message of a commit, instead of running it,
+// it reports false when the command has to run, because dry-run mode is off or the command does not mutate
+func RecordDryRun(args ...string) bool {
+	if !dryRun || !IsMutatingGit(args) {
+		return false
+	}
+
+	var quoted []string
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'$`\\|&;<>()*?") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+
+	fmt.Fprintln(os.Stderr, color.YellowString("[dry-run]"), strings.Join(quoted, " "))
+	if sub, rest := gitSubcommand(args[1:]); sub == "commit" || sub == "tag" {
+		if msg := dryRunMessage(rest); msg != "" {
+			for _, line := range strings.Split(strings.TrimRight(msg, "\n"), "\n") {
+				fmt.Fprintln(os.Stderr, "    "+line)
+			}
+		}
+	}
+	return true
+}
+
+// gitSubcommand skips the global options of git, e.g. -C <path> or -c <name>=<value>
+func gitSubcommand(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-C" || arg == "-c":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return arg, args[i+1:]
+		}
+	}
+	return "", nil
+}
+
+// dryRunMessage returns the message given by -m or -F
+func dryRunMessage(args []string) string {
+	var msgs []string
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "-m", "--message":
+			msgs = append(msgs, args[i+1])
+		case "-F", "--file":
+			if data, err := os.ReadFile(args[i+1]); err == nil {
+				msgs = append(msgs, string(data))
+			}
+		}
+	}
+	return strings.Join(msgs, "\n\n")
+}
+
+func hasPositional(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool { return !strings.HasPrefix(arg, "-") })
+}
+
+func isListFlag(arg string) bool {
+	return arg == "-l" || arg == "--list" || arg == "-a" || arg == "-r" || arg == "--contains" || arg == "--merged" ||
+		arg == "--no-merged" || arg == "--points-at" || strings.HasPrefix(arg, "-n") || strings.HasPrefix(arg, "--format")
+}
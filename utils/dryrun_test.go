@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+func TestIsMutatingGit(t *testing.T) {
+	cases := []struct {
+		cmd      string
+		mutating bool
+	}{
+		{"git status --porcelain", false},
+		{"git diff --cached --name-only", false},
+		{"git fetch --prune --tags", false},
+		{"git tag -n --sort=-committerdate", false},
+		{"git tag", false},
+		{"git branch -a --format=%(refname:short)", false},
+		{"git branch -r --contains main", false},
+		{"git worktree list --porcelain", false},
+		{"git -C /tmp log -1", false},
+		{"ls -la", false},
+		{"git add --update", true},
+		{"git commit --amend --no-edit -m msg", true},
+		{"git push --force-with-lease origin main", true},
+		{"git pull --no-rebase", true},
+		{"git tag v1.0.0", true},
+		{"git tag -d v1.0.0", true},
+		{"git branch -D feat", true},
+		{"git branch --set-upstream-to=origin/main main", true},
+		{"git worktree add ../repo-1 -b feat main", true},
+		{"git -C /tmp -c user.name=a commit -m msg", true},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.mutating, IsMutatingGit(strings.Fields(c.cmd)), c.cmd)
+	}
+}
+
+func TestGetStagedDiffDryRun(t *testing.T) {
+	repo := gittest.New(t)
+	useGitDir(t, repo.Dir)
+	SetDryRun(true)
+	t.Cleanup(func() { SetDryRun(false) })
+
+	repo.Commit("main.go", "package main\n", "feat: add main")
+	repo.Commit("go.mod", "module example.com/a\n", "feat: add go.mod")
+	repo.Write("main.go", "package main\n\nfunc main() {}\n")
+	repo.Write("go.mod", "module example.com/b\n")
+	repo.Git("add", "go.mod")
+	repo.Write("new.go", "package main\n")
+
+	// git add --update is only printed, the unstaged changes of the tracked files are part of the diff
+	_, err := RunGit(context.Background(), "add", "--update")
+	require.NoError(t, err)
+
+	diff, err := GetStagedDiff(context.Background(), nil).UnwrapErr()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go.mod", "main.go"}, diff.Files)
+	assert.Contains(t, diff.Diff, "+func main() {}")
+	assert.Contains(t, diff.Diff, "+module example.com/b")
+	assert.Equal(t, "M  go.mod\n M main.go\n?? new.go", repo.Git("status", "--short"))
+}
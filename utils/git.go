@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -12,7 +11,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/bitfield/script"
 	"github.com/briandowns/spinner"
 	"github.com/pubgo/funk/v2/assert"
 	"github.com/pubgo/funk/v2/log"
@@ -30,35 +28,78 @@ func (e *KnownError) Error() string {
 	return e.Message
 }
 
-// ExcludeFromDiff 生成 Git 排除路径的格式
+// ExcludeFromDiff 生成 Git 排除路径的格式, 路径相对于仓库根目录
 func ExcludeFromDiff(path string) string {
-	return fmt.Sprintf(":(exclude)%s", path)
+	return fmt.Sprintf(":(top,exclude)%s", path)
 }
 
 type GetStagedDiffRsp struct {
-	Files []string `json:"files"`
-	Diff  string   `json:"diff"`
+	Files    []string `json:"files"`
+	Excluded []string `json:"excluded"`
+	Diff     string   `json:"diff"`
 }
 
-// GetStagedDiff 获取暂存区的差异
-func GetStagedDiff(ctx context.Context, excludeFiles ...string) (r result.Result[*GetStagedDiffRsp]) {
+// ExcludedNote 生成被排除文件的说明, 让 prompt 中仍然包含这些文件名
+func (r *GetStagedDiffRsp) ExcludedNote() string {
+	if len(r.Excluded) == 0 {
+		return ""
+	}
+
+	return "# files changed but excluded from the diff:\n# " + strings.Join(r.Excluded, "\n# ")
+}
+
+// GetStagedDiff 获取暂存区的差异, 被 excluder 匹配的文件以及二进制文件只保留文件名,
+// dry-run 模式下 git add --update 没有执行, 获取已跟踪文件在工作区与 HEAD 之间的差异
+func GetStagedDiff(ctx context.Context, excluder *DiffExcluder) (r result.Result[*GetStagedDiffRsp]) {
 	defer result.Recovery(&r)
-	diffCached := []string{"git", "diff", "--cached", "--diff-algorithm=minimal"}
+	// 重命名按删除和新增列出, numstat 的路径才不会是 old => new 的形式
+	diffCached := []string{"git", "diff", "--cached", "--diff-algorithm=minimal", "--no-renames"}
+	if IsDryRun() {
+		if _, err := RunGit(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+			diffCached = []string{"git", "diff", "HEAD", "--diff-algorithm=minimal", "--no-renames"}
+		}
+	}
 
 	// 获取暂存区文件的名称
-	filesOutput := ShellExecOutput(ctx, append(diffCached, append([]string{"--name-only"}, excludeFiles...)...)...).Unwrap()
+	filesOutput := ShellExecOutput(ctx, append(diffCached, "--name-only")...).Unwrap()
 
 	files := strings.Split(strings.TrimSpace(filesOutput), "\n")
 	if len(files) == 0 || files[0] == "" {
 		return r.WithValue(new(GetStagedDiffRsp))
 	}
 
-	// 获取暂存区的完整差异
-	diffOutput := ShellExecOutput(ctx, append(diffCached, excludeFiles...)...).Unwrap()
+	// numstat 中二进制文件的增删行数为 -
+	binaryFiles := make(map[string]bool)
+	numstatOutput := ShellExecOutput(ctx, append(diffCached, "--numstat")...).Unwrap()
+	for _, line := range strings.Split(numstatOutput, "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) == 3 && parts[0] == "-" && parts[1] == "-" {
+			binaryFiles[parts[2]] = true
+		}
+	}
+
+	var excluded []string
+	var pathspecs []string
+	for _, file := range files {
+		if binaryFiles[file] || (excluder != nil && excluder.Match(file)) {
+			excluded = append(excluded, file)
+			pathspecs = append(pathspecs, ExcludeFromDiff(file))
+		}
+	}
+
+	var diffOutput string
+	if len(excluded) < len(files) {
+		// 获取暂存区的完整差异
+		if len(pathspecs) > 0 {
+			pathspecs = append([]string{"--"}, pathspecs...)
+		}
+		diffOutput = ShellExecOutput(ctx, append(diffCached, pathspecs...)...).Unwrap()
+	}
 
 	return r.WithValue(&GetStagedDiffRsp{
-		Files: files,
-		Diff:  strings.TrimSpace(diffOutput),
+		Files:    files,
+		Excluded: excluded,
+		Diff:     strings.TrimSpace(diffOutput),
 	})
 }
 
@@ -72,35 +113,40 @@ func GetDetectedMessage(files []string) string {
 	return fmt.Sprintf("detected %d staged file%s", fileCount, pluralSuffix)
 }
 
-func GitPushTag(ctx context.Context, ver string) string {
+// GitPushTag creates the tag at HEAD and pushes it to origin, opts nil creates a lightweight tag
+func GitPushTag(ctx context.Context, ver string, opts *TagOptions) *PushResult {
 	if ver == "" {
-		return ""
+		return new(PushResult)
 	}
 
 	log.Info().Msg("git push tag " + ver)
-	assert.Must(ShellExec(ctx, "git", "tag", ver))
+	assert.Must(GitCreateTag(ctx, ver, opts))
 	return GitPush(ctx, "origin", ver)
 }
 
+// GitCreateTag creates the tag at HEAD, opts nil creates a lightweight tag
+func GitCreateTag(ctx context.Context, tag string, opts *TagOptions) error {
+	log.Info().Bool("annotated", opts != nil).Bool("signed", opts != nil && opts.Sign).Msg("git tag " + tag)
+	_, err := RunGit(ctx, opts.args(tag)...)
+	return err
+}
+
 func GitFetchAll(ctx context.Context) {
 	assert.Must(ShellExec(ctx, "git", "fetch", "--prune", "--tags"))
 }
 
 func IsDirty() (r result.Result[bool]) {
-	output := result.Wrap(script.Exec("git status --porcelain").String()).
+	status := result.Wrap(GetRepoStatus(context.Background())).
 		Log(func(e *zerolog.Event) {
-			e.Str(logfields.Msg, "failed to gitRun git")
+			e.Str(logfields.Msg, "failed to get git status")
 		})
 
-	return result.MapTo(output, func(output string) bool {
-		return len(strings.TrimSpace(output)) > 0
-	})
+	return result.MapTo(status, (*RepoStatus).IsDirty)
 }
 
 func GetCommitCount(branch string) (r result.Result[int]) {
-	shell := fmt.Sprintf("git rev-list %s --count", branch)
-	output := result.Wrap(script.Exec(shell).String()).Log(func(e *zerolog.Event) {
-		e.Str(logfields.Msg, fmt.Sprintf("failed to gitRun shell %q", shell))
+	output := result.Wrap(gitRun("rev-list", branch, "--count")).Log(func(e *zerolog.Event) {
+		e.Str(logfields.Msg, fmt.Sprintf("failed to count the commits of %q", branch))
 	})
 
 	return result.FlatMapTo(output, func(count string) result.Result[int] {
@@ -112,67 +158,61 @@ func GetCommitCount(branch string) (r result.Result[int]) {
 }
 
 func GetCurrentBranch() result.Result[string] {
-	shell := "git branch --show-current"
-	return result.Wrap(script.Exec(shell).String()).
+	return result.Wrap(gitRun("branch", "--show-current")).
 		Map(func(s string) string {
 			return strings.TrimSpace(s)
 		}).
 		MapErr(func(err error) error {
-			return fmt.Errorf("failed to gitRun shell %q, err=%w", shell, err)
+			return fmt.Errorf("failed to get the current branch, err=%w", err)
 		})
 }
 
 func PushTag(tag string) result.Error {
-	shell := fmt.Sprintf("git push origin %s", tag)
-	return result.ErrOf(script.Exec(shell).Error()).MapErr(func(err error) error {
-		return fmt.Errorf("failed to gitRun shell %q, err=%w", shell, err)
+	_, err := gitRun("push", "origin", tag)
+	return result.ErrOf(err).MapErr(func(err error) error {
+		return fmt.Errorf("failed to push tag %q, err=%w", tag, err)
 	})
 }
 
 func GetRepositoryName() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+	output, err := gitRun("rev-parse", "--show-toplevel")
 	if err != nil {
 		return "", fmt.Errorf("not in a git repository: %w", err)
 	}
 
-	repoPath := strings.TrimSpace(string(output))
+	repoPath := strings.TrimSpace(output)
 	return filepath.Base(repoPath), nil
 }
 
 // IsGitRepository checks if the current directory is inside a git repository
 func IsGitRepository() bool {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	err := cmd.Run()
+	_, err := gitRun("rev-parse", "--git-dir")
 	return err == nil
 }
 
 func GetCurrentBranchV1() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	output, err := cmd.Output()
+	output, err := gitRun("rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
 	}
 
-	return strings.TrimSpace(string(output)), nil
+	return strings.TrimSpace(output), nil
 }
 
 func ListAllBranches() ([]string, error) {
 	// First, fetch to ensure we have the latest remote branches
-	fetchCmd := exec.Command("git", "fetch", "--prune")
-	if err := fetchCmd.Run(); err != nil {
+	if _, err := gitRun("fetch", "--prune"); err != nil {
 		// Continue even if fetch fails
 		fmt.Printf("Warning: failed to fetch latest branches: %v\n", err)
 	}
 
 	// Get all branches (local and remote)
-	cmd := exec.Command("git", "branch", "-a", "--format=%(refname:short)")
-	output, err := cmd.Output()
+	output, err := gitRun("branch", "-a", "--format=%(refname:short)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list branches: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
 	var branches []string
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -186,8 +226,7 @@ func ListAllBranches() ([]string, error) {
 
 func BranchExists(branch string) (bool, error) {
 	// Check if it's a local branch
-	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", branch)
-	if err := cmd.Run(); err == nil {
+	if _, err := gitRun("rev-parse", "--verify", "--quiet", branch); err == nil {
 		return true, nil
 	}
 
@@ -197,8 +236,7 @@ func BranchExists(branch string) (bool, error) {
 		remoteRef = "origin/" + branch
 	}
 
-	cmd = exec.Command("git", "rev-parse", "--verify", "--quiet", remoteRef)
-	if err := cmd.Run(); err == nil {
+	if _, err := gitRun("rev-parse", "--verify", "--quiet", remoteRef); err == nil {
 		return true, nil
 	}
 
@@ -207,23 +245,20 @@ func BranchExists(branch string) (bool, error) {
 
 func DeleteBranch(branch string) error {
 	// Use -D flag to force delete even if not merged
-	cmd := exec.Command("git", "branch", "-D", branch)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to delete branch %s: %s", branch, string(output))
+	if _, err := gitRun("branch", "-D", branch); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", branch, err)
 	}
 	return nil
 }
 
 // HasUncommittedChanges checks if there are uncommitted changes in the current worktree
 func HasUncommittedChanges() (bool, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
-	output, err := cmd.Output()
+	status, err := GetRepoStatus(context.Background())
 	if err != nil {
 		return false, fmt.Errorf("failed to check git status: %w", err)
 	}
 
-	return strings.TrimSpace(string(output)) != "", nil
+	return status.IsDirty(), nil
 }
 
 // HasUnpushedCommits checks if there are unpushed commits in the current branch
@@ -234,8 +269,7 @@ func HasUnpushedCommits() (bool, error) {
 	}
 
 	// Check if the branch has an upstream
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", branch+"@{upstream}")
-	if err := cmd.Run(); err != nil {
+	if _, err := gitRun("rev-parse", "--abbrev-ref", branch+"@{upstream}"); err != nil {
 		// No upstream branch configured
 		// Check if the branch is already merged to main/master
 		// This handles the case where the branch was merged and remote was deleted
@@ -251,13 +285,12 @@ func HasUnpushedCommits() (bool, error) {
 	}
 
 	// Check if there are commits ahead of upstream
-	cmd = exec.Command("git", "rev-list", "--count", branch+"@{upstream}.."+branch)
-	output, err := cmd.Output()
+	output, err := gitRun("rev-list", "--count", branch+"@{upstream}.."+branch)
 	if err != nil {
 		return false, fmt.Errorf("failed to check unpushed commits: %w", err)
 	}
 
-	count := strings.TrimSpace(string(output))
+	count := strings.TrimSpace(output)
 	return count != "0", nil
 }
 
@@ -269,19 +302,17 @@ func IsMergedToOrigin(targetBranch string) (bool, error) {
 	}
 
 	// Fetch the latest state from origin
-	cmd := exec.Command("git", "fetch", "origin", targetBranch)
-	if err := cmd.Run(); err != nil {
+	if _, err := gitRun("fetch", "origin", targetBranch); err != nil {
 		return false, fmt.Errorf("failed to fetch origin: %w", err)
 	}
 
 	// Check if the current branch is merged into origin/targetBranch
-	cmd = exec.Command("git", "branch", "-r", "--contains", currentBranch)
-	output, err := cmd.Output()
+	output, err := gitRun("branch", "-r", "--contains", currentBranch)
 	if err != nil {
 		return false, fmt.Errorf("failed to check merge status: %w", err)
 	}
 
-	branches := strings.Split(string(output), "\n")
+	branches := strings.Split(output, "\n")
 	targetRef := fmt.Sprintf("origin/%s", targetBranch)
 
 	for _, branch := range branches {
@@ -331,12 +362,11 @@ func CreateWorktree(issueNumberOrBranch, baseBranch string) (string, error) {
 	}
 
 	// Get repository root directory
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+	output, err := gitRun("rev-parse", "--show-toplevel")
 	if err != nil {
 		return "", fmt.Errorf("failed to get repository root: %w", err)
 	}
-	repoRoot := strings.TrimSpace(string(output))
+	repoRoot := strings.TrimSpace(output)
 
 	// Determine branch name and directory suffix
 	branchName, dirSuffix := DetermineWorktreeNames(issueNumberOrBranch)
@@ -345,11 +375,7 @@ func CreateWorktree(issueNumberOrBranch, baseBranch string) (string, error) {
 	worktreeDir := filepath.Join(repoRoot, "..", fmt.Sprintf("%s-%s", repoName, dirSuffix))
 
 	// Create the worktree
-	cmd = exec.Command("git", "worktree", "add", worktreeDir, "-b", branchName, baseBranch)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if _, err := gitRun("worktree", "add", worktreeDir, "-b", branchName, baseBranch); err != nil {
 		return "", fmt.Errorf("failed to create worktree: %w", err)
 	}
 
@@ -374,12 +400,11 @@ func RemoveWorktree(issueNumberOrBranch string) error {
 	}
 
 	// Get repository root directory
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+	output, err := gitRun("rev-parse", "--show-toplevel")
 	if err != nil {
 		return fmt.Errorf("failed to get repository root: %w", err)
 	}
-	repoRoot := strings.TrimSpace(string(output))
+	repoRoot := strings.TrimSpace(output)
 
 	// Determine directory suffix
 	_, dirSuffix := DetermineWorktreeNames(issueNumberOrBranch)
@@ -396,11 +421,7 @@ func RemoveWorktreeByPath(worktreePath string) error {
 	}
 
 	// Remove the worktree
-	cmd := exec.Command("git", "worktree", "remove", worktreePath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if _, err := gitRun("worktree", "remove", worktreePath); err != nil {
 		return fmt.Errorf("failed to remove worktree: %w", err)
 	}
 
@@ -409,14 +430,13 @@ func RemoveWorktreeByPath(worktreePath string) error {
 
 // ListWorktrees returns a list of all worktrees
 func ListWorktrees() ([]WorktreeInfo, error) {
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
+	output, err := gitRun("worktree", "list", "--porcelain")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
 
 	var worktrees []WorktreeInfo
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	var current WorktreeInfo
 
 	for _, line := range lines {
@@ -497,19 +517,16 @@ func CreateWorktreeFromBranch(worktreePath, sourceBranch, targetBranch string) e
 	// Check if source branch starts with origin/
 	isRemoteBranch := strings.HasPrefix(sourceBranch, "origin/")
 
-	var cmd *exec.Cmd
+	var args []string
 	if isRemoteBranch {
 		// For remote branches, create a new local branch tracking the remote
-		cmd = exec.Command("git", "worktree", "add", worktreePath, "-b", targetBranch, sourceBranch)
+		args = []string{"worktree", "add", worktreePath, "-b", targetBranch, sourceBranch}
 	} else {
 		// For local branches, just check it out
-		cmd = exec.Command("git", "worktree", "add", worktreePath, sourceBranch)
+		args = []string{"worktree", "add", worktreePath, sourceBranch}
 	}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if _, err := gitRun(args...); err != nil {
 		return fmt.Errorf("failed to create worktree: %w", err)
 	}
 
@@ -646,12 +663,40 @@ func Commit(message string) error {
 	return err
 }
 
+// CommitFile commits with the message read from a file, so that the body and trailers keep their formatting.
+func CommitFile(message string) error {
+	f, err := os.CreateTemp("", "fastcommit-msg-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create commit message file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(message); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write commit message file: %w", err)
+	}
+	f.Close()
+
+	// the lines starting with '#', e.g. markdown headings, are part of the message
+	output, err := gitRun("commit", "--cleanup=scissors", "-F", f.Name())
+	if output != "" {
+		log.Info().Msgf("shell result: \n%s\n", output)
+	}
+	return err
+}
+
 // CommitAmend amends the last commit with a new message.
 func CommitAmend(message string) error {
 	_, err := gitRun("commit", "--amend", "-m", message)
 	return err
 }
 
+// HeadCommit returns the hash of the HEAD commit.
+func HeadCommit() (string, error) {
+	output, err := gitRun("rev-parse", "HEAD")
+	return strings.TrimSpace(output), err
+}
+
 // LastCommitAuthor returns the author name and email of the last commit.
 func LastCommitAuthor() (name, email string, err error) {
 	output, err := gitRun("log", "-1", "--format=%an|%ae")
@@ -669,31 +714,22 @@ func LastCommitAuthor() (name, email string, err error) {
 
 // IsAheadOfRemote checks if the current branch is ahead of remote.
 func IsAheadOfRemote() (bool, error) {
-	output, err := gitRun("status", "-sb")
+	status, err := GetRepoStatus(context.Background())
 	if err != nil {
 		return false, err
 	}
 
-	return strings.Contains(output, "ahead"), nil
+	return status.Ahead > 0, nil
 }
 
-// gitRun executes a git command and returns its output.
+// gitRun executes a git command and returns its stdout.
 func gitRun(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		if stderr.Len() > 0 {
-			return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), stderr.String())
-		}
-
-		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	res, err := RunGit(context.Background(), args...)
+	if err != nil {
+		return "", err
 	}
 
-	return stdout.String(), nil
+	return res.Stdout, nil
 }
 
 func GitPull(ctx context.Context, args ...string) (r result.Error) {
@@ -701,8 +737,8 @@ func GitPull(ctx context.Context, args ...string) (r result.Error) {
 
 	//	"git", "pull", "--no-rebase"
 	now := time.Now()
-	args = append([]string{"git", "pull"}, args...)
-	output := result.Async(func() result.Result[string] { return ShellExecOutput(ctx, args...) })
+	args = append([]string{"pull"}, args...)
+	output := result.Async(func() result.Result[string] { return gitOutput(ctx, args...) })
 	time.Sleep(time.Millisecond * 20)
 
 	spin := spinner.New(spinner.CharSets[35], 100*time.Millisecond, func(s *spinner.Spinner) { s.Prefix = "git pull: " })
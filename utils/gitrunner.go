@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/pubgo/funk/v2/errors"
+)
+
+// GitResult is the output of a git command, stdout and stderr are kept apart
+type GitResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Output joins stdout and stderr, git push and pull report on stderr
+func (r *GitResult) Output() string {
+	return strings.TrimSpace(strings.Join(
+		slices.DeleteFunc([]string{strings.TrimSpace(r.Stdout), strings.TrimSpace(r.Stderr)}, func(s string) bool { return s == "" }),
+		"\n"))
+}
+
+// GitError is returned when git exits with a non-zero code
+type GitError struct {
+	Args   []string
+	Result *GitResult
+}
+
+func (e *GitError) Error() string {
+	if stderr := strings.TrimSpace(e.Result.Stderr); stderr != "" {
+		return fmt.Sprintf("git %s failed: %s", strings.Join(e.Args, " "), stderr)
+	}
+	return fmt.Sprintf("git %s failed: exit status %d", strings.Join(e.Args, " "), e.Result.ExitCode)
+}
+
+// IsGitExitCode reports whether err is a GitError with the exit code
+func IsGitExitCode(err error, code int) bool {
+	var gitErr *GitError
+	return errors.As(err, &gitErr) && gitErr.Result.ExitCode == code
+}
+
+// Git runs a git command, the args are passed to git as they are, without a shell in between
+type Git interface {
+	Run(ctx context.Context, args ...string) (*GitResult, error)
+}
+
+var defaultGit Git = NewExecGit("")
+
+// SetGit replaces the Git every git command of fastcommit runs with, e.g. by a fake in tests
+func SetGit(g Git) { defaultGit = g }
+
+// GetGit returns the Git every git command of fastcommit runs with
+func GetGit() Git { return defaultGit }
+
+// RunGit runs git with the args, in dry-run mode the commands which change the repository are only printed
+func RunGit(ctx context.Context, args ...string) (*GitResult, error) {
+	if RecordDryRun(append([]string{"git"}, args...)...) {
+		return new(GitResult), nil
+	}
+	return defaultGit.Run(ctx, args...)
+}
+
+// NewExecGit returns a Git which runs the git binary in dir, an empty dir is the current directory
+func NewExecGit(dir string) Git { return &execGit{dir: dir} }
+
+type execGit struct {
+	dir string
+}
+
+func (g *execGit) Run(ctx context.Context, args ...string) (*GitResult, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	err := cmd.Run()
+	res := &GitResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		res.ExitCode = exitErr.ExitCode()
+		return res, &GitError{Args: args, Result: res}
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		return res, errors.Wrapf(err, "failed to run git %s", strings.Join(args, " "))
+	}
+	return res, nil
+}
+
+// NewGoGit returns a Git which answers the read-only commands it knows from go-git without starting a process,
+// every other command runs with the fallback
+func NewGoGit(dir string, fallback Git) (Git, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open git repository %q", dir)
+	}
+	return &goGit{repo: repo, fallback: fallback}, nil
+}
+
+type goGit struct {
+	repo     *git.Repository
+	fallback Git
+}
+
+func (g *goGit) Run(ctx context.Context, args ...string) (*GitResult, error) {
+	var stdout string
+	var err error
+	switch strings.Join(args, " ") {
+	case "rev-parse HEAD":
+		stdout, err = g.head(false)
+	case "branch --show-current":
+		stdout, err = g.head(true)
+	case "tag", "tag --list":
+		stdout, err = g.tags()
+	case "status --porcelain":
+		stdout, err = g.status()
+	default:
+		return g.fallback.Run(ctx, args...)
+	}
+
+	if err != nil {
+		return &GitResult{Stderr: err.Error(), ExitCode: 128}, errors.Wrapf(err, "failed to run git %s", strings.Join(args, " "))
+	}
+	return &GitResult{Stdout: stdout}, nil
+}
+
+func (g *goGit) head(branch bool) (string, error) {
+	ref, err := g.repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	if !branch {
+		return ref.Hash().String() + "\n", nil
+	}
+
+	// a detached head has no current branch
+	if !ref.Name().IsBranch() {
+		return "", nil
+	}
+	return ref.Name().Short() + "\n", nil
+}
+
+func (g *goGit) tags() (string, error) {
+	iter, err := g.repo.Tags()
+	if err != nil {
+		return "", err
+	}
+
+	var tags []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	slices.Sort(tags)
+	return joinLines(tags), nil
+}
+
+func (g *goGit) status() (string, error) {
+	wt, err := g.repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return "", err
+	}
+
+	// the lines are "XY path", sorted by the path like git does
+	lines := strings.Split(strings.TrimRight(status.String(), "\n"), "\n")
+	slices.SortFunc(lines, func(a, b string) int { return strings.Compare(a[min(3, len(a)):], b[min(3, len(b)):]) })
+	return joinLines(slices.DeleteFunc(lines, func(s string) bool { return s == "" })), nil
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
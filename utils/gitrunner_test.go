@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+type fakeGit struct {
+	calls   [][]string
+	results map[string]*GitResult
+}
+
+func (f *fakeGit) Run(ctx context.Context, args ...string) (*GitResult, error) {
+	f.calls = append(f.calls, args)
+	res, ok := f.results[strings.Join(args, " ")]
+	if !ok {
+		return new(GitResult), nil
+	}
+
+	if res.ExitCode != 0 {
+		return res, &GitError{Args: args, Result: res}
+	}
+	return res, nil
+}
+
+func useFakeGit(t *testing.T, results map[string]*GitResult) *fakeGit {
+	fake := &fakeGit{results: results}
+	prev := GetGit()
+	SetGit(fake)
+	t.Cleanup(func() { SetGit(prev) })
+	return fake
+}
+
+// useGitDir runs the git commands of the test in dir
+func useGitDir(t *testing.T, dir string) {
+	prev := GetGit()
+	SetGit(NewExecGit(dir))
+	t.Cleanup(func() { SetGit(prev) })
+}
+
+func TestGitArgsWithoutShell(t *testing.T) {
+	fake := useFakeGit(t, map[string]*GitResult{
+		"push origin main": {Stderr: " ! [rejected] main -> main (fetch first)", ExitCode: 1},
+		"push origin dev":  {Stderr: "fatal: 'origin' does not appear to be a git repository", ExitCode: 128},
+		"tag v1.0.0":       {Stderr: "fatal: tag 'v1.0.0' already exists", ExitCode: 128},
+	})
+
+	msg := `fix: handle "quoted" $HOME and 'single' quotes`
+	require.NoError(t, ShellExec(context.Background(), "git", "commit", "-m", msg))
+	require.NoError(t, Commit(msg))
+	require.NoError(t, CommitAmend(msg))
+	assert.Equal(t, [][]string{{"commit", "-m", msg}, {"commit", "-m", msg}, {"commit", "--amend", "-m", msg}}, fake.calls)
+
+	// every non-zero exit code is an error, the caller checks the exit code
+	_, err := ShellExecOutput(context.Background(), "git", "push", "origin", "main").UnwrapErr()
+	assert.True(t, IsGitExitCode(err, 1), "rejected push err = %v", err)
+	assert.ErrorContains(t, err, "[rejected]")
+
+	_, err = ShellExecOutput(context.Background(), "git", "push", "origin", "dev").UnwrapErr()
+	assert.True(t, IsGitExitCode(err, 128), "failed push err = %v", err)
+
+	err = GitCreateTag(context.Background(), "v1.0.0", nil)
+	assert.True(t, IsGitExitCode(err, 128), "existing tag err = %v", err)
+}
+
+func TestGoGitMatchesExecGit(t *testing.T) {
+	ctx := context.Background()
+	repo := gittest.New(t)
+	repo.Write("b.txt", "b.txt")
+	repo.Commit("a.txt", "a.txt", "feat: add a")
+	repo.Git("tag", "v0.1.0")
+	repo.Git("tag", "v0.0.1")
+	repo.Write("a.txt", "changed")
+
+	execGit := NewExecGit(repo.Dir)
+	goGit, err := NewGoGit(repo.Dir, execGit)
+	require.NoError(t, err)
+
+	for _, args := range [][]string{
+		{"rev-parse", "HEAD"},
+		{"branch", "--show-current"},
+		{"tag"},
+		{"status", "--porcelain"},
+	} {
+		want, err := execGit.Run(ctx, args...)
+		require.NoError(t, err)
+
+		got, err := goGit.Run(ctx, args...)
+		require.NoError(t, err)
+		assert.Equal(t, want.Stdout, got.Stdout, "git %s", strings.Join(args, " "))
+	}
+
+	res, err := goGit.Run(ctx, "rev-parse", "--verify", "--quiet", "missing")
+	assert.True(t, IsGitExitCode(err, 1), "fallback err = %v", err)
+	assert.Equal(t, 1, res.ExitCode)
+}
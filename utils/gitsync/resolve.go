@@ -0,0 +1,97 @@
+package gitsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/samber/lo"
+	"github.com/yarlson/tap"
+
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/conflictui"
+	"github.com/pubgo/fastcommit/utils/llmclient"
+)
+
+// NewResolver resolves the conflicts in the conflict view, provider is called once there are conflicts and may return nil,
+// in non-interactive mode nobody can resolve them and the sync is aborted
+func NewResolver(provider func() llmclient.Provider) ResolveFunc {
+	return func(ctx context.Context, status *utils.RepoStatus) error {
+		files := status.Conflicts()
+		fmt.Fprintln(utils.Stdout(), "❌ Conflicts detected! Please resolve them.")
+		if !utils.IsInteractive() {
+			fmt.Fprintf(os.Stderr, "conflicts in: %s\n", strings.Join(files, ", "))
+			return ErrAborted
+		}
+
+		root, err := repoRoot(ctx)
+		if err != nil {
+			return err
+		}
+
+		editor, err := utils.GetEditor().UnwrapErr()
+		if err != nil {
+			log.Warn().Err(err).Msg("no editor, the conflicts can only be resolved in the conflict view")
+		}
+
+		resolved, err := conflictui.Run(ctx, root, files, editor, conflictui.OfferSuggestions(ctx, provider()))
+		if err != nil {
+			return err
+		}
+
+		if resolved {
+			return nil
+		}
+
+		if utils.PromptConfirm(ctx, tap.ConfirmOptions{
+			Message:      fmt.Sprintf("Not every conflict is resolved, abort the %s?", lo.Ternary(status.Rebasing, "rebase", "merge")),
+			InitialValue: false,
+		}) {
+			return ErrAborted
+		}
+		return errors.Wrapf(ErrUnresolvedConflicts, "files: %s", strings.Join(files, ", "))
+	}
+}
+
+// stageResolved runs git add for the files without conflict markers, and returns the files which still have them
+func stageResolved(ctx context.Context, files []string) ([]string, error) {
+	root, err := repoRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved, unresolved []string
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.WrapCaller(err)
+		}
+
+		if conflict, err := utils.ParseConflictFile(file, data); err != nil || len(conflict.Hunks) > 0 {
+			unresolved = append(unresolved, file)
+		} else {
+			resolved = append(resolved, file)
+		}
+	}
+
+	if len(resolved) > 0 {
+		// git add -A stages the deletion of a removed file as well
+		args := append([]string{"-C", root, "add", "-A", "--"}, resolved...)
+		if _, err := utils.RunGit(ctx, args...); err != nil {
+			return nil, errors.Wrap(err, "failed to stage the resolved files")
+		}
+	}
+	return unresolved, nil
+}
+
+func repoRoot(ctx context.Context) (string, error) {
+	res, err := utils.RunGit(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the repository root")
+	}
+	return strings.TrimSpace(res.Stdout), nil
+}
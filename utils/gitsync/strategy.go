@@ -0,0 +1,63 @@
+package gitsync
+
+import (
+	"github.com/pubgo/funk/v2/log"
+)
+
+// Strategy is how the local branch takes the commits of its upstream
+type Strategy string
+
+const (
+	Merge           Strategy = "merge"
+	Rebase          Strategy = "rebase"
+	RebaseAutostash Strategy = "rebase-autostash"
+	FFOnly          Strategy = "ff-only"
+)
+
+// Strategies are the valid values of sync.strategy
+var Strategies = []string{string(Merge), string(Rebase), string(RebaseAutostash), string(FFOnly)}
+
+type Config struct {
+	// Strategy is one of merge, rebase, rebase-autostash or ff-only, default: merge
+	Strategy Strategy `yaml:"strategy"`
+}
+
+// GetStrategy returns the configured strategy, merge when it is not set or unknown
+func (c *Config) GetStrategy() Strategy {
+	if c == nil || c.Strategy == "" {
+		return Merge
+	}
+
+	if !c.Strategy.Valid() {
+		log.Warn().Str("strategy", string(c.Strategy)).Msg("unknown sync strategy, use merge")
+		return Merge
+	}
+	return c.Strategy
+}
+
+// Valid reports whether the strategy is known
+func (s Strategy) Valid() bool {
+	switch s {
+	case Merge, Rebase, RebaseAutostash, FFOnly:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRebase reports whether the local commits are replayed on top of the upstream
+func (s Strategy) IsRebase() bool { return s == Rebase || s == RebaseAutostash }
+
+// integrateArgs are the git arguments which integrate the upstream into the branch
+func (s Strategy) integrateArgs(upstream string) []string {
+	switch s {
+	case Rebase:
+		return []string{"rebase", upstream}
+	case RebaseAutostash:
+		return []string{"rebase", "--autostash", upstream}
+	case FFOnly:
+		return []string{"merge", "--ff-only", upstream}
+	default:
+		return []string{"merge", "--no-edit", upstream}
+	}
+}
@@ -0,0 +1,253 @@
+package gitsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/samber/lo"
+
+	"github.com/pubgo/fastcommit/utils"
+)
+
+// State is a step of the sync
+type State string
+
+const (
+	StateFetch     State = "fetch"
+	StateIntegrate State = "integrate"
+	StateConflicts State = "conflicts"
+	StateContinue  State = "continue"
+	StateAbort     State = "abort"
+	StatePush      State = "push"
+	StateDone      State = "done"
+)
+
+var (
+	// ErrAborted is returned by a ResolveFunc to abort the merge or rebase
+	ErrAborted = errors.New("sync aborted")
+
+	// ErrUnresolvedConflicts is returned when files still have conflicts or conflict markers
+	ErrUnresolvedConflicts = errors.New("there are unresolved conflicts")
+)
+
+// ResolveFunc resolves the conflicts of the status, the files without conflict markers are staged afterwards
+type ResolveFunc func(ctx context.Context, status *utils.RepoStatus) error
+
+// Syncer takes the commits of remote/branch with the strategy and pushes the result
+type Syncer struct {
+	Strategy Strategy
+
+	// Remote is origin when it is empty
+	Remote string
+	Branch string
+
+	// Resolve resolves the conflicts, the merge or rebase is aborted on conflicts when it is nil
+	Resolve ResolveFunc
+
+	// PushArgs are the arguments of git push after the integration, empty skips the push
+	PushArgs []string
+
+	// Retries is how often fetch is repeated when the push is rejected again because the remote moved meanwhile
+	Retries int
+}
+
+// Result is the outcome of Syncer.Run
+type Result struct {
+	// States are the visited states in order
+	States []State
+	Status *utils.RepoStatus
+	Push   *utils.PushResult
+}
+
+// Run walks the states fetch → integrate → conflicts → continue or abort → push → done,
+// a merge or rebase which stopped on conflicts before is resumed at the conflicts
+func (s *Syncer) Run(ctx context.Context) (*Result, error) {
+	remote := s.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	upstream := remote + "/" + s.Branch
+
+	status, err := utils.GetRepoStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = new(Result)
+	var retries = s.Retries
+	var state = StateFetch
+	switch {
+	case status.Merging || status.Rebasing:
+		res.Status = status
+		state = lo.Ternary(len(status.Conflicts()) > 0, StateConflicts, StateContinue)
+	case status.InProgress():
+		return nil, errors.New("a cherry-pick or revert is in progress, conclude it first")
+	}
+
+	for {
+		res.States = append(res.States, state)
+		log.Info().Str("state", string(state)).Str("strategy", string(s.Strategy)).Str("upstream", upstream).Msg("sync")
+
+		switch state {
+		case StateFetch:
+			if _, err := utils.RunGit(ctx, "fetch", remote, s.Branch); err != nil {
+				return res, errors.Wrapf(err, "failed to fetch %s", upstream)
+			}
+			state = StateIntegrate
+		case StateIntegrate:
+			gitRes, integrateErr := utils.RunGit(ctx, s.Strategy.integrateArgs(upstream)...)
+			if gitRes != nil && gitRes.Output() != "" {
+				log.Info().Msgf("shell result: \n%s\n", gitRes.Output())
+			}
+
+			if res.Status, err = utils.GetRepoStatus(ctx); err != nil {
+				return res, err
+			}
+
+			switch {
+			case len(res.Status.Conflicts()) > 0:
+				state = StateConflicts
+			case integrateErr != nil && s.Strategy == FFOnly:
+				return res, errors.Wrapf(integrateErr, "%s can not be fast-forwarded to %s, use the merge or rebase sync strategy", s.Branch, upstream)
+			case integrateErr != nil:
+				return res, errors.Wrapf(integrateErr, "failed to %s %s", s.Strategy, upstream)
+			default:
+				state = StatePush
+			}
+		case StateConflicts:
+			if s.Resolve == nil {
+				err = errors.Wrapf(ErrUnresolvedConflicts, "files: %s", strings.Join(res.Status.Conflicts(), ", "))
+				state = StateAbort
+				continue
+			}
+
+			if err = s.Resolve(ctx, res.Status); errors.Is(err, ErrAborted) {
+				state = StateAbort
+				continue
+			}
+
+			if err != nil {
+				return res, err
+			}
+			state = StateContinue
+		case StateContinue:
+			if res.Status, err = continueSync(ctx); err != nil {
+				log.Err(err).Msg("resolve the conflicts, run 'git add' and 'git rebase --continue' or 'git commit'")
+				return res, err
+			}
+			state = lo.Ternary(len(res.Status.Conflicts()) > 0, StateConflicts, StatePush)
+		case StateAbort:
+			if abortErr := abortSync(ctx, res.Status); abortErr != nil {
+				return res, errors.Wrapf(abortErr, "failed to abort, %v", err)
+			}
+			return res, err
+		case StatePush:
+			if len(s.PushArgs) == 0 {
+				state = StateDone
+				continue
+			}
+
+			res.Push = utils.GitPush(ctx, s.PushArgs...)
+			if res.Push.NeedPull() && retries > 0 {
+				retries--
+				state = StateFetch
+				continue
+			}
+
+			if err := res.Push.AsError(); err != nil {
+				return res, err
+			}
+			state = StateDone
+		case StateDone:
+			if res.Status, err = utils.GetRepoStatus(ctx); err != nil {
+				return res, err
+			}
+
+			if res.Push == nil && res.Status.Ahead > 0 {
+				informUserToPush(s.Strategy, upstream, res.Status.Ahead)
+			}
+			return res, nil
+		}
+	}
+}
+
+// continueSync stages the conflicted files without conflict markers and concludes the merge or continues the rebase,
+// the status has the conflicts of the next commit when the rebase stopped again
+func continueSync(ctx context.Context) (*utils.RepoStatus, error) {
+	status, err := utils.GetRepoStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if conflicts := status.Conflicts(); len(conflicts) > 0 {
+		unresolved, err := stageResolved(ctx, conflicts)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(unresolved) > 0 {
+			return nil, errors.Wrapf(ErrUnresolvedConflicts, "files: %s", strings.Join(unresolved, ", "))
+		}
+	}
+
+	switch {
+	case status.Rebasing:
+		// the editor would ask for the message of every replayed commit
+		res, err := utils.RunGit(ctx, "-c", "core.editor=true", "rebase", "--continue")
+		if err != nil && res != nil && strings.Contains(res.Output(), "No changes") {
+			// the resolution dropped every change of the commit
+			_, err = utils.RunGit(ctx, "rebase", "--skip")
+		}
+
+		if err != nil && !utils.IsGitExitCode(err, 1) {
+			return nil, errors.Wrap(err, "failed to continue the rebase")
+		}
+	case status.Merging:
+		if _, err := utils.RunGit(ctx, "commit", "--no-edit"); err != nil {
+			return nil, errors.Wrap(err, "failed to conclude the merge")
+		}
+	}
+
+	status, err = utils.GetRepoStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.InProgress() && len(status.Conflicts()) == 0 {
+		return nil, errors.Errorf("git stopped without conflicts, conclude it by hand")
+	}
+	return status, nil
+}
+
+// abortSync restores the branch as it was before the merge or rebase
+func abortSync(ctx context.Context, status *utils.RepoStatus) error {
+	switch {
+	case status.Rebasing:
+		_, err := utils.RunGit(ctx, "rebase", "--abort")
+		return err
+	case status.Merging:
+		_, err := utils.RunGit(ctx, "merge", "--abort")
+		return err
+	default:
+		return nil
+	}
+}
+
+// 提示用户如何继续
+func informUserToPush(strategy Strategy, upstream string, ahead int) {
+	fmt.Fprintln(utils.Stdout(), "\n----------------------------------------")
+	fmt.Fprintf(utils.Stdout(), "🛠️  Synced with %s, %d local commits are not pushed.\n", upstream, ahead)
+	fmt.Fprintln(utils.Stdout(), "Now you can:")
+	fmt.Fprintln(utils.Stdout(), "   1. Review changes")
+	fmt.Fprintln(utils.Stdout(), "   2. Then do:")
+	fmt.Fprintln(utils.Stdout(), "      git push")
+	if strategy == Merge {
+		fmt.Fprintln(utils.Stdout(), "")
+		fmt.Fprintln(utils.Stdout(), "💡 Tip: 如果你想保持线性历史，不产生 merge commit，可以设置 rebase 同步策略：")
+		fmt.Fprintln(utils.Stdout(), "    FASTCOMMIT_SYNC=rebase fastcommit commit")
+	}
+	fmt.Fprintln(utils.Stdout(), "----------------------------------------")
+}
@@ -0,0 +1,108 @@
+package gitsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+// newTestRepos creates a bare remote with the commit base, and the clones local and other of it,
+// other pushed remote and local committed local, both changed a.txt
+func newTestRepos(t *testing.T, remoteContent, localContent string) (local, other *gittest.Repo) {
+	remote := gittest.NewBare(t)
+	local, other = remote.Clone(), remote.Clone()
+	other.Commit("a.txt", "base\n", "base")
+	other.Git("push", "-q", "origin", "main")
+	local.Git("pull", "-q", "origin", "main")
+	local.Git("branch", "-q", "--set-upstream-to=origin/main")
+
+	other.Commit("a.txt", remoteContent, "remote")
+	other.Git("push", "-q", "origin", "main")
+	local.Commit("b.txt", "", "local")
+	if localContent != "" {
+		local.Commit("a.txt", localContent, "local a")
+	}
+
+	prev := utils.GetGit()
+	utils.SetGit(utils.NewExecGit(local.Dir))
+	t.Cleanup(func() { utils.SetGit(prev) })
+	return local, other
+}
+
+func TestSyncRebaseConflict(t *testing.T) {
+	local, other := newTestRepos(t, "remote\n", "local\n")
+
+	var resolved int
+	s := &Syncer{
+		Strategy: Rebase,
+		Branch:   "main",
+		PushArgs: []string{"origin", "main"},
+		Resolve: func(ctx context.Context, status *utils.RepoStatus) error {
+			assert.True(t, status.Rebasing)
+			assert.Equal(t, []string{"a.txt"}, status.Conflicts())
+
+			// the first call leaves the conflict markers
+			if resolved++; resolved > 1 {
+				local.Write("a.txt", "remote\nlocal\n")
+			}
+			return nil
+		},
+	}
+
+	_, err := s.Run(context.Background())
+	require.ErrorIs(t, err, ErrUnresolvedConflicts)
+
+	// the rebase waits for the resolution, the next run resumes it
+	res, err := s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []State{StateConflicts, StateContinue, StatePush, StateDone}, res.States)
+	assert.True(t, res.Push.OK(), "push = %v", res.Push.Status())
+
+	other.Git("pull", "-q", "origin", "main")
+	assert.Equal(t, "local a\nlocal\nremote\nbase", other.Git("log", "--format=%s"), "a linear history")
+}
+
+func TestSyncMerge(t *testing.T) {
+	local, _ := newTestRepos(t, "remote\n", "")
+
+	res, err := (&Syncer{Strategy: Merge, Branch: "main"}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []State{StateFetch, StateIntegrate, StatePush, StateDone}, res.States)
+	assert.Nil(t, res.Push)
+	assert.Equal(t, 2, res.Status.Ahead)
+	assert.Equal(t, 0, res.Status.Behind)
+	assert.Len(t, strings.Fields(local.Git("log", "-1", "--format=%p")), 2, "a merge commit")
+}
+
+func TestSyncAbort(t *testing.T) {
+	local, _ := newTestRepos(t, "remote\n", "local\n")
+	head := local.Git("rev-parse", "HEAD")
+
+	for _, strategy := range []Strategy{Merge, Rebase} {
+		for _, resolve := range []ResolveFunc{nil, func(context.Context, *utils.RepoStatus) error { return ErrAborted }} {
+			res, err := (&Syncer{Strategy: strategy, Branch: "main", Resolve: resolve}).Run(context.Background())
+			assert.Error(t, err, strategy)
+			assert.Equal(t, StateAbort, res.States[len(res.States)-1], strategy)
+
+			status, err := utils.GetRepoStatus(context.Background())
+			require.NoError(t, err)
+			assert.False(t, status.InProgress(), strategy)
+			assert.False(t, status.IsDirty(), strategy)
+			assert.Equal(t, head, local.Git("rev-parse", "HEAD"), "%s restores the branch", strategy)
+		}
+	}
+}
+
+func TestSyncFFOnly(t *testing.T) {
+	newTestRepos(t, "remote\n", "")
+
+	res, err := (&Syncer{Strategy: FFOnly, Branch: "main"}).Run(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []State{StateFetch, StateIntegrate}, res.States)
+}
@@ -0,0 +1,105 @@
+// Package gittest creates the temporary git repositories of the tests
+package gittest
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Repo is a git repository in a temporary directory of a test
+type Repo struct {
+	t   testing.TB
+	Dir string
+}
+
+// New creates a repository with the branch main and a test committer
+func New(t testing.TB) *Repo {
+	t.Helper()
+	r := &Repo{t: t, Dir: t.TempDir()}
+	r.Git("init", "-q", "-b", "main")
+	r.configUser()
+	return r
+}
+
+// NewBare creates a bare repository with the branch main, the remote of the clones
+func NewBare(t testing.TB) *Repo {
+	t.Helper()
+	r := &Repo{t: t, Dir: filepath.Join(t.TempDir(), "remote.git")}
+	run(t, "", "init", "-q", "--bare", "-b", "main", r.Dir)
+	return r
+}
+
+// Clone clones the repository into a temporary directory and checks out main
+func (r *Repo) Clone() *Repo {
+	r.t.Helper()
+	clone := &Repo{t: r.t, Dir: filepath.Join(r.t.TempDir(), "clone")}
+	run(r.t, "", "clone", "-q", r.Dir, clone.Dir)
+	clone.configUser()
+	if clone.Git("branch", "--show-current") != "main" {
+		clone.Git("checkout", "-q", "-b", "main")
+	}
+	return clone
+}
+
+func (r *Repo) configUser() {
+	r.Git("config", "user.name", "test")
+	r.Git("config", "user.email", "test@example.com")
+	r.Git("config", "commit.gpgsign", "false")
+	r.Git("config", "tag.gpgsign", "false")
+}
+
+// Git runs git in the repository and returns the trimmed stdout, the test fails when git fails
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+	return run(r.t, r.Dir, args...)
+}
+
+// Run runs git in the repository and returns the trimmed stdout, a failure is returned to the test, e.g. of a conflict
+func (r *Repo) Run(args ...string) (string, error) {
+	r.t.Helper()
+	stdout, _, err := runGit(r.Dir, args...)
+	return stdout, err
+}
+
+// Write writes the file, the slash separated name is relative to the repository
+func (r *Repo) Write(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// Commit writes the file and commits it with the message
+func (r *Repo) Commit(name, content, msg string) {
+	r.t.Helper()
+	r.Write(name, content)
+	r.Git("add", name)
+	r.Git("commit", "-q", "-m", msg)
+}
+
+func run(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	stdout, stderr, err := runGit(dir, args...)
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, stderr)
+	}
+	return stdout
+}
+
+func runGit(dir string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	return strings.TrimSpace(stdout.String()), stderr.String(), err
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/format/gitignore"
+)
+
+// DiffIgnoreFile is the per repository file in gitignore syntax listing the files excluded from the ai diff
+const DiffIgnoreFile = ".fastcommitignore"
+
+// DefaultDiffExcludes are the lock, generated, vendored and minified files which never go into the ai diff
+var DefaultDiffExcludes = []string{
+	"go.sum",
+	"go.work.sum",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Cargo.lock",
+	"poetry.lock",
+	"composer.lock",
+	"Gemfile.lock",
+	"*.pb.go",
+	"*.pb.gw.go",
+	"*_gen.go",
+	"vendor/",
+	"node_modules/",
+	"*.min.js",
+	"*.min.css",
+	"*.map",
+}
+
+type DiffExcluder struct {
+	matcher gitignore.Matcher
+}
+
+// NewDiffExcluder combines the default excludes with the patterns of .fastcommitignore in the repo root,
+// repo patterns take precedence, so "!go.sum" brings go.sum back into the diff
+func NewDiffExcluder(repoPath string) *DiffExcluder {
+	var patterns []gitignore.Pattern
+	for _, p := range DefaultDiffExcludes {
+		patterns = append(patterns, gitignore.ParsePattern(p, nil))
+	}
+
+	if data, err := os.ReadFile(filepath.Join(repoPath, DiffIgnoreFile)); err == nil {
+		patterns = append(patterns, ParseIgnorePatterns(data)...)
+	}
+
+	return &DiffExcluder{matcher: gitignore.NewMatcher(patterns)}
+}
+
+// Match reports whether the file, relative to the repo root, is excluded from the diff
+func (e *DiffExcluder) Match(file string) bool {
+	return e.matcher.Match(strings.Split(filepath.ToSlash(file), "/"), false)
+}
+
+// ParseIgnorePatterns parses the content of a file in gitignore syntax
+func ParseIgnorePatterns(data []byte) []gitignore.Pattern {
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return patterns
+}
@@ -0,0 +1,49 @@
+package utils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils"
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+func TestDiffExcluder(t *testing.T) {
+	repo := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(repo, utils.DiffIgnoreFile), []byte("# generated\ndocs/api/\n*.snap\n!go.sum\n"), 0644))
+
+	excluder := utils.NewDiffExcluder(repo)
+	assert.True(t, excluder.Match("package-lock.json"))
+	assert.True(t, excluder.Match("web/app.min.js"))
+	assert.True(t, excluder.Match("vendor/github.com/a/b/c.go"))
+	assert.True(t, excluder.Match("proto/user.pb.go"))
+	assert.True(t, excluder.Match("docs/api/index.html"))
+	assert.True(t, excluder.Match("ui/__snapshots__/a.snap"))
+	assert.False(t, excluder.Match("go.sum"))
+	assert.False(t, excluder.Match("main.go"))
+	assert.False(t, excluder.Match("docs/readme.md"))
+}
+
+func TestGetStagedDiffRenamedBinary(t *testing.T) {
+	repo := gittest.New(t)
+	prev := utils.GetGit()
+	utils.SetGit(utils.NewExecGit(repo.Dir))
+	t.Cleanup(func() { utils.SetGit(prev) })
+
+	repo.Commit("assets/logo.png", "\x89PNG\x00\x01\x02", "feat: add logo")
+	repo.Git("mv", "assets/logo.png", "assets/icon.png")
+	repo.Write("main.go", "package main\n")
+	repo.Git("add", "-A")
+
+	diff, err := utils.GetStagedDiff(context.Background(), nil).UnwrapErr()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"assets/icon.png", "assets/logo.png", "main.go"}, diff.Files)
+	assert.ElementsMatch(t, []string{"assets/icon.png", "assets/logo.png"}, diff.Excluded)
+	assert.Contains(t, diff.Diff, "+package main")
+	assert.NotContains(t, diff.Diff, "PNG")
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/yarlson/tap"
+)
+
+// ErrNonInteractive is returned when an input has no default and fastcommit runs in non-interactive mode
+var ErrNonInteractive = errors.New("input is required, but fastcommit runs in non-interactive mode, see --yes")
+
+var nonInteractive bool
+
+// SetNonInteractive enables the non-interactive mode, in which every prompt takes its default
+func SetNonInteractive(v bool) { nonInteractive = v }
+
+// IsInteractive reports whether prompts are shown to the user
+func IsInteractive() bool { return !nonInteractive }
+
+// DetectNonInteractive reports whether stdin or stdout is not a terminal, or fastcommit runs in CI
+func DetectNonInteractive() bool {
+	if ci, err := strconv.ParseBool(os.Getenv("CI")); err == nil && ci {
+		return true
+	}
+	return !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd())
+}
+
+// PromptText shows tap.Text, in non-interactive mode the initial value, or else the default value, is returned
+func PromptText(ctx context.Context, opts tap.TextOptions) string {
+	if IsInteractive() {
+		return tap.Text(ctx, opts)
+	}
+
+	value := opts.InitialValue
+	if value == "" {
+		value = opts.DefaultValue
+	}
+
+	if opts.Validate != nil && opts.Validate(value) != nil {
+		return ""
+	}
+	return value
+}
+
+// PromptConfirm shows tap.Confirm, in non-interactive mode the initial value is returned
+func PromptConfirm(ctx context.Context, opts tap.ConfirmOptions) bool {
+	if IsInteractive() {
+		return tap.Confirm(ctx, opts)
+	}
+	return opts.InitialValue
+}
+
+// PromptSelect shows tap.Select, in non-interactive mode the initial value, or else the first option, is returned
+func PromptSelect[T any](ctx context.Context, opts tap.SelectOptions[T]) T {
+	if IsInteractive() {
+		return tap.Select[T](ctx, opts)
+	}
+
+	var value T
+	switch {
+	case opts.InitialValue != nil:
+		value = *opts.InitialValue
+	case len(opts.Options) > 0:
+		value = opts.Options[0].Value
+	}
+	return value
+}
+
+// PromptMultiSelect shows tap.MultiSelect, in non-interactive mode the initial values are returned
+func PromptMultiSelect[T any](ctx context.Context, opts tap.MultiSelectOptions[T]) []T {
+	if IsInteractive() {
+		return tap.MultiSelect[T](ctx, opts)
+	}
+	return opts.InitialValues
+}
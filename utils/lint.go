@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	LintRuleScheme            = "scheme"
+	LintRuleSubjectEmpty      = "subject-empty"
+	LintRuleSubjectMaxLength  = "subject-max-length"
+	LintRuleSubjectFullStop   = "subject-full-stop"
+	LintRuleSubjectImperative = "subject-imperative"
+	LintRuleBodyLeadingBlank  = "body-leading-blank"
+)
+
+// LintIssue is a rule which the commit message violates
+type LintIssue struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (i *LintIssue) String() string { return fmt.Sprintf("%s: %s", i.Rule, i.Message) }
+
+// Linter checks commit messages against the commit scheme and the subject rules
+type Linter struct {
+	Scheme *CommitScheme
+
+	// MaxLength is the max length of the subject without the type and scope, 0 disables the check
+	MaxLength int
+
+	// Imperative enables the english imperative mood heuristics
+	Imperative bool
+}
+
+var skipLintRegexp = regexp.MustCompile(`^(Merge |Revert "|fixup! |squash! |amend! |Initial commit)`)
+
+// Lint returns the issues of the message, merge, revert, fixup and squash messages are not checked
+func (l *Linter) Lint(msg string) []*LintIssue {
+	msg, _, _ = strings.Cut(msg, ScissorsLine)
+
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(msg, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) == "" {
+		return []*LintIssue{{Rule: LintRuleSubjectEmpty, Message: "the commit message is empty"}}
+	}
+
+	subject := strings.TrimSpace(lines[0])
+	if skipLintRegexp.MatchString(subject) {
+		return nil
+	}
+
+	var issues []*LintIssue
+	if l.Scheme != nil {
+		if err := l.Scheme.Validate(subject); err != nil {
+			issues = append(issues, &LintIssue{Rule: LintRuleScheme, Message: err.Error()})
+		}
+	}
+
+	description := subjectDescription(subject)
+	if n := utf8.RuneCountInString(description); l.MaxLength > 0 && n > l.MaxLength {
+		issues = append(issues, &LintIssue{
+			Rule:    LintRuleSubjectMaxLength,
+			Message: fmt.Sprintf("the subject has %d characters, the max length is %d", n, l.MaxLength),
+		})
+	}
+
+	if strings.HasSuffix(subject, ".") || strings.HasSuffix(subject, "。") {
+		issues = append(issues, &LintIssue{Rule: LintRuleSubjectFullStop, Message: "the subject must not end with a period"})
+	}
+
+	if l.Imperative {
+		if word, suggestion, ok := NonImperativeWord(description); ok {
+			issues = append(issues, &LintIssue{
+				Rule:    LintRuleSubjectImperative,
+				Message: fmt.Sprintf("use the imperative mood, %q instead of %q", suggestion, word),
+			})
+		}
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		issues = append(issues, &LintIssue{Rule: LintRuleBodyLeadingBlank, Message: "the body must be separated from the subject by a blank line"})
+	}
+
+	return issues
+}
+
+// FormatLintIssues joins the issues into one line per issue
+func FormatLintIssues(issues []*LintIssue) string {
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// subjectDescription strips the emoji, type and scope from the subject
+func subjectDescription(subject string) string {
+	if m := subjectRegexp.FindStringSubmatch(subject); m != nil {
+		return m[5]
+	}
+
+	// gitmoji subject, or a plain subject
+	if first, rest, ok := strings.Cut(subject, " "); ok {
+		if r, _ := utf8.DecodeRuneInString(first); r > utf8.RuneSelf {
+			return rest
+		}
+	}
+	return subject
+}
+
+// imperativeVerbs are the verbs commonly found at the start of commit subjects
+var imperativeVerbs = []string{
+	"add", "allow", "avoid", "bump", "change", "clean", "configure", "convert", "create", "delete", "deprecate",
+	"disable", "drop", "enable", "ensure", "extract", "fix", "handle", "implement", "improve", "include", "initialize",
+	"introduce", "make", "merge", "migrate", "move", "optimize", "prevent", "refactor", "reduce", "release", "remove",
+	"rename", "replace", "restore", "revert", "rewrite", "set", "simplify", "skip", "split", "stop", "support",
+	"switch", "update", "upgrade", "use", "validate",
+}
+
+var nonImperativeForms = sync.OnceValue(func() map[string]string {
+	var forms = make(map[string]string)
+	for _, verb := range imperativeVerbs {
+		for _, form := range verbForms(verb) {
+			forms[form] = verb
+		}
+	}
+	forms["made"], forms["rewrote"], forms["rewritten"], forms["splitting"] = "make", "rewrite", "rewrite", "split"
+	return forms
+})
+
+// verbForms returns the third person, past tense and gerund of a regular verb
+func verbForms(verb string) []string {
+	last := verb[len(verb)-1]
+	switch {
+	case last == 'e':
+		stem := verb[:len(verb)-1]
+		return []string{verb + "s", verb + "d", stem + "ing"}
+	case last == 'y' && !isVowel(verb[len(verb)-2]):
+		stem := verb[:len(verb)-1]
+		return []string{stem + "ies", stem + "ied", verb + "ing"}
+	case strings.HasSuffix(verb, "ch") || strings.HasSuffix(verb, "sh") || last == 'x' || last == 's':
+		return []string{verb + "es", verb + "ed", verb + "ing"}
+	case len(verb) <= 4 && !isVowel(last) && isVowel(verb[len(verb)-2]) && !isVowel(verb[len(verb)-3]) && last != 'w':
+		// one syllable verbs double the final consonant, e.g. stop -> stopped
+		double := verb + string(last)
+		return []string{verb + "s", double + "ed", double + "ing"}
+	default:
+		return []string{verb + "s", verb + "ed", verb + "ing"}
+	}
+}
+
+// NonImperativeWord reports whether the first word of the description is a common verb in past tense,
+// third person or gerund, e.g. "added", "adds" or "adding" instead of "add"
+func NonImperativeWord(description string) (word string, suggestion string, ok bool) {
+	fields := strings.Fields(description)
+	if len(fields) == 0 {
+		return "", "", false
+	}
+
+	word = fields[0]
+	suggestion, ok = nonImperativeForms()[strings.ToLower(word)]
+	return word, suggestion, ok
+}
+
+func isVowel(c byte) bool { return strings.IndexByte("aeiou", c) >= 0 }
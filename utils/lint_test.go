@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinter(t *testing.T) {
+	scheme, _ := GetCommitScheme(ConventionalCommitType)
+	linter := &Linter{Scheme: scheme, MaxLength: 50, Imperative: true}
+
+	rules := func(msg string) []string {
+		var res []string
+		for _, issue := range linter.Lint(msg) {
+			res = append(res, issue.Rule)
+		}
+		return res
+	}
+
+	cases := []struct {
+		msg   string
+		rules []string
+	}{
+		{"feat(llm): add streaming", nil},
+		{"feat(llm): add streaming\n\nwhy it matters\n# Please enter the commit message", nil},
+		{"Merge branch 'main' into feat", nil},
+		{"fixup! feat: add streaming", nil},
+		{"", []string{LintRuleSubjectEmpty}},
+		{"add streaming", []string{LintRuleScheme}},
+		{"feat: added streaming.", []string{LintRuleSubjectFullStop, LintRuleSubjectImperative}},
+		{"fix: fixes the nil pointer", []string{LintRuleSubjectImperative}},
+		{"fix: stopping the spinner", []string{LintRuleSubjectImperative}},
+		{"docs: process docs", nil},
+		{"feat: " + "a very long subject which goes way beyond fifty chars", []string{LintRuleSubjectMaxLength}},
+		{"feat: add streaming\nwithout a blank line", []string{LintRuleBodyLeadingBlank}},
+		{"feat: add streaming\n# ------------------------ >8 ------------------------\ndiff --git a/b", nil},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.rules, rules(c.msg), c.msg)
+	}
+
+	word, suggestion, ok := NonImperativeWord("Updated the readme")
+	assert.True(t, ok)
+	assert.Equal(t, "Updated", word)
+	assert.Equal(t, "update", suggestion)
+}
@@ -0,0 +1,191 @@
+package llmclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+var _ Provider = (*anthropicProvider)(nil)
+
+const anthropicVersion = "2023-06-01"
+
+type AnthropicConfig struct {
+	ApiKey    string `yaml:"api_key"`
+	BaseURL   string `yaml:"base_url"`
+	Model     string `yaml:"model"`
+	MaxTokens int    `yaml:"max_tokens"`
+}
+
+type anthropicProvider struct {
+	cfg *AnthropicConfig
+}
+
+func NewAnthropic(cfg *AnthropicConfig) (Provider, error) {
+	if cfg == nil || cfg.ApiKey == "" {
+		return nil, errors.New("anthropic api_key is required")
+	}
+
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.anthropic.com"
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	if cfg.Model == "" {
+		return nil, errors.New("anthropic model is required")
+	}
+
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 1024
+	}
+
+	return &anthropicProvider{cfg: cfg}, nil
+}
+
+type anthropicRequest struct {
+	Model       string    `json:"model"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	Temperature *float32  `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+func (p *anthropicProvider) Name() string { return ProviderAnthropic }
+
+func (p *anthropicProvider) Model() string { return p.cfg.Model }
+
+func (p *anthropicProvider) Generate(ctx context.Context, req *Request) (*Response, error) {
+	var resp anthropicResponse
+	if err := postJSON(ctx, p.cfg.BaseURL+"/v1/messages", p.headers(), p.buildRequest(req), &resp); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return &Response{
+		Choices: []string{text.String()},
+		Usage:   anthropicToUsage(resp.Usage),
+	}, nil
+}
+
+func (p *anthropicProvider) Stream(ctx context.Context, req *Request, onDelta func(delta string)) (*Response, error) {
+	body := p.buildRequest(req)
+	body.Stream = true
+
+	rsp, err := doJSON(ctx, p.cfg.BaseURL+"/v1/messages", p.headers(), body)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Close()
+
+	var usage anthropicUsage
+	var content strings.Builder
+	scanner := bufio.NewScanner(rsp)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode anthropic event: %s", data)
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				continue
+			}
+
+			content.WriteString(event.Delta.Text)
+			onDelta(event.Delta.Text)
+		case "error":
+			return nil, errors.Errorf("anthropic stream error: %s", data)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+
+	return &Response{
+		Choices: []string{content.String()},
+		Usage:   anthropicToUsage(usage),
+	}, nil
+}
+
+func (p *anthropicProvider) CountTokens(ctx context.Context, msgs ...Message) (int, error) {
+	body := p.buildRequest(&Request{Messages: msgs})
+	body.MaxTokens = 0
+
+	var resp anthropicUsage
+	if err := postJSON(ctx, p.cfg.BaseURL+"/v1/messages/count_tokens", p.headers(), body, &resp); err != nil {
+		return 0, err
+	}
+	return resp.InputTokens, nil
+}
+
+func (p *anthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.cfg.ApiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+func (p *anthropicProvider) buildRequest(req *Request) *anthropicRequest {
+	return &anthropicRequest{
+		Model:       p.cfg.Model,
+		MaxTokens:   p.cfg.MaxTokens,
+		System:      req.System(),
+		Messages:    req.Conversation(),
+		Temperature: req.Temperature,
+	}
+}
+
+func anthropicToUsage(usage anthropicUsage) Usage {
+	return Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.InputTokens + usage.OutputTokens,
+	}
+}
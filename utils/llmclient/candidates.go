@@ -0,0 +1,73 @@
+package llmclient
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+// GenerateCandidates returns up to n distinct candidates,
+// providers which ignore the n parameter are called in parallel to fill the missing candidates
+func GenerateCandidates(ctx context.Context, p Provider, req *Request, n int) (*Response, error) {
+	if n <= 1 {
+		return p.Generate(ctx, req)
+	}
+
+	first := *req
+	first.N = n
+	resp, err := p.Generate(ctx, &first)
+	if err != nil {
+		return nil, err
+	}
+
+	if missing := n - len(resp.Choices); missing > 0 {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var errs []error
+		for range missing {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				single := *req
+				single.N = 0
+				rsp, err := p.Generate(ctx, &single)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+
+				resp.Choices = append(resp.Choices, rsp.Choices...)
+				resp.Usage = resp.Usage.Add(rsp.Usage)
+			}()
+		}
+		wg.Wait()
+
+		if len(resp.Choices) == 0 {
+			return nil, errors.Join(errs...)
+		}
+	}
+
+	resp.Choices = uniqueChoices(resp.Choices)
+	return resp, nil
+}
+
+func uniqueChoices(choices []string) []string {
+	var seen = make(map[string]bool, len(choices))
+	var res = make([]string, 0, len(choices))
+	for _, choice := range choices {
+		choice = strings.TrimSpace(choice)
+		if choice == "" || seen[choice] {
+			continue
+		}
+
+		seen[choice] = true
+		res = append(res, choice)
+	}
+	return res
+}
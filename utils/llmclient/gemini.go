@@ -0,0 +1,147 @@
+package llmclient
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+	"google.golang.org/genai"
+)
+
+var _ Provider = (*geminiProvider)(nil)
+
+type GeminiConfig struct {
+	ApiKey  string `yaml:"api_key"`
+	BaseURL string `yaml:"base_url"`
+	Model   string `yaml:"model"`
+}
+
+type geminiProvider struct {
+	client *genai.Client
+	cfg    *GeminiConfig
+}
+
+func NewGemini(cfg *GeminiConfig) (Provider, error) {
+	if cfg == nil || cfg.ApiKey == "" {
+		return nil, errors.New("gemini api_key is required")
+	}
+
+	if cfg.Model == "" {
+		cfg.Model = "gemini-2.5-flash"
+	}
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      cfg.ApiKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: cfg.BaseURL},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gemini client")
+	}
+
+	return &geminiProvider{client: client, cfg: cfg}, nil
+}
+
+func (p *geminiProvider) Name() string { return ProviderGemini }
+
+func (p *geminiProvider) Model() string { return p.cfg.Model }
+
+func (p *geminiProvider) Generate(ctx context.Context, req *Request) (*Response, error) {
+	contents, genCfg := p.buildRequest(req)
+	resp, err := p.client.Models.GenerateContent(ctx, p.cfg.Model, contents, genCfg)
+	if err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+
+	var rsp = &Response{Usage: geminiUsage(resp)}
+	for _, candidate := range resp.Candidates {
+		rsp.Choices = append(rsp.Choices, geminiText(candidate))
+	}
+	return rsp, nil
+}
+
+func (p *geminiProvider) Stream(ctx context.Context, req *Request, onDelta func(delta string)) (*Response, error) {
+	contents, genCfg := p.buildRequest(req)
+	genCfg.CandidateCount = 0
+
+	var rsp = new(Response)
+	var content strings.Builder
+	for chunk, err := range p.client.Models.GenerateContentStream(ctx, p.cfg.Model, contents, genCfg) {
+		if err != nil {
+			return nil, errors.WrapCaller(err)
+		}
+
+		if chunk.UsageMetadata != nil {
+			rsp.Usage = geminiUsage(chunk)
+		}
+
+		delta := chunk.Text()
+		if delta == "" {
+			continue
+		}
+
+		content.WriteString(delta)
+		onDelta(delta)
+	}
+
+	rsp.Choices = []string{content.String()}
+	return rsp, nil
+}
+
+func (p *geminiProvider) CountTokens(ctx context.Context, msgs ...Message) (int, error) {
+	// the gemini api does not accept system instructions for counting, so every message is counted as user content
+	var contents []*genai.Content
+	for _, msg := range msgs {
+		contents = append(contents, genai.NewContentFromText(msg.Content, genai.RoleUser))
+	}
+
+	resp, err := p.client.Models.CountTokens(ctx, p.cfg.Model, contents, nil)
+	if err != nil {
+		return 0, errors.WrapCaller(err)
+	}
+	return int(resp.TotalTokens), nil
+}
+
+func (p *geminiProvider) buildRequest(req *Request) ([]*genai.Content, *genai.GenerateContentConfig) {
+	var genCfg = &genai.GenerateContentConfig{Temperature: req.Temperature, CandidateCount: int32(req.N)}
+	if system := req.System(); system != "" {
+		genCfg.SystemInstruction = genai.NewContentFromText(system, genai.RoleUser)
+	}
+
+	var contents []*genai.Content
+	for _, msg := range req.Conversation() {
+		role := genai.Role(genai.RoleUser)
+		if msg.Role == RoleAssistant {
+			role = genai.RoleModel
+		}
+		contents = append(contents, genai.NewContentFromText(msg.Content, role))
+	}
+	return contents, genCfg
+}
+
+func geminiText(candidate *genai.Candidate) string {
+	if candidate == nil || candidate.Content == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if part.Thought {
+			continue
+		}
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
+
+func geminiUsage(resp *genai.GenerateContentResponse) Usage {
+	if resp.UsageMetadata == nil {
+		return Usage{}
+	}
+
+	return Usage{
+		PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
+		CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+		TotalTokens:      int(resp.UsageMetadata.TotalTokenCount),
+	}
+}
@@ -0,0 +1,55 @@
+package llmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// doJSON posts the json body and returns the response body, the caller must close it
+func doJSON(ctx context.Context, url string, headers map[string]string, body any) (io.ReadCloser, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, errors.Errorf("request %s failed, status=%d body=%s", url, resp.StatusCode, msg)
+	}
+
+	return resp.Body, nil
+}
+
+func postJSON(ctx context.Context, url string, headers map[string]string, body any, out any) error {
+	rsp, err := doJSON(ctx, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer rsp.Close()
+
+	return errors.WrapCaller(json.NewDecoder(rsp).Decode(out))
+}
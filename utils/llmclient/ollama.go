@@ -0,0 +1,139 @@
+package llmclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+var _ Provider = (*ollamaProvider)(nil)
+
+type OllamaConfig struct {
+	BaseURL string `yaml:"base_url"`
+	Model   string `yaml:"model"`
+}
+
+type ollamaProvider struct {
+	cfg *OllamaConfig
+}
+
+func NewOllama(cfg *OllamaConfig) (Provider, error) {
+	if cfg == nil || cfg.Model == "" {
+		return nil, errors.New("ollama model is required")
+	}
+
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "http://localhost:11434"
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &ollamaProvider{cfg: cfg}, nil
+}
+
+type ollamaRequest struct {
+	Model    string         `json:"model"`
+	Messages []Message      `json:"messages"`
+	Stream   bool           `json:"stream"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	Error           string  `json:"error"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+}
+
+func (r *ollamaResponse) usage() Usage {
+	return Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+func (p *ollamaProvider) Name() string { return ProviderOllama }
+
+func (p *ollamaProvider) Model() string { return p.cfg.Model }
+
+func (p *ollamaProvider) Generate(ctx context.Context, req *Request) (*Response, error) {
+	var resp ollamaResponse
+	if err := postJSON(ctx, p.cfg.BaseURL+"/api/chat", nil, p.buildRequest(req, false), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error != "" {
+		return nil, errors.Errorf("ollama error: %s", resp.Error)
+	}
+
+	return &Response{
+		Choices: []string{resp.Message.Content},
+		Usage:   resp.usage(),
+	}, nil
+}
+
+func (p *ollamaProvider) Stream(ctx context.Context, req *Request, onDelta func(delta string)) (*Response, error) {
+	rsp, err := doJSON(ctx, p.cfg.BaseURL+"/api/chat", nil, p.buildRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Close()
+
+	var res = new(Response)
+	var content strings.Builder
+	scanner := bufio.NewScanner(rsp)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode ollama chunk: %s", line)
+		}
+
+		if chunk.Error != "" {
+			return nil, errors.Errorf("ollama error: %s", chunk.Error)
+		}
+
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+
+		if chunk.Done {
+			res.Usage = chunk.usage()
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+
+	res.Choices = []string{content.String()}
+	return res, nil
+}
+
+func (p *ollamaProvider) CountTokens(ctx context.Context, msgs ...Message) (int, error) {
+	return estimateTokens(msgs...), nil
+}
+
+func (p *ollamaProvider) buildRequest(req *Request, stream bool) *ollamaRequest {
+	var body = &ollamaRequest{
+		Model:    p.cfg.Model,
+		Messages: req.Messages,
+		Stream:   stream,
+	}
+
+	if req.Temperature != nil {
+		body.Options = map[string]any{"temperature": *req.Temperature}
+	}
+	return body
+}
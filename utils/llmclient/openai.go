@@ -0,0 +1,159 @@
+package llmclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/sashabaranov/go-openai"
+	"github.com/tiktoken-go/tokenizer"
+
+	"github.com/pubgo/fastcommit/utils"
+)
+
+var _ Provider = (*openaiProvider)(nil)
+
+type openaiProvider struct {
+	client *utils.OpenaiClient
+}
+
+// NewOpenai creates the provider for openai and openai compatible apis, e.g. deepseek
+func NewOpenai(client *utils.OpenaiClient) (Provider, error) {
+	if client == nil || client.Cfg == nil {
+		return nil, errors.New("openai config is required")
+	}
+
+	if client.Cfg.ApiKey == "" {
+		return nil, errors.New("openai api_key is required")
+	}
+
+	return &openaiProvider{client: client}, nil
+}
+
+func (p *openaiProvider) Name() string { return ProviderOpenai }
+
+func (p *openaiProvider) Model() string { return p.client.Cfg.Model }
+
+func (p *openaiProvider) Generate(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.client.Client.CreateChatCompletion(ctx, p.buildRequest(req))
+	if err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+
+	var rsp = &Response{Usage: Usage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}}
+	for _, choice := range resp.Choices {
+		rsp.Choices = append(rsp.Choices, choice.Message.Content)
+	}
+	return rsp, nil
+}
+
+func (p *openaiProvider) Stream(ctx context.Context, req *Request, onDelta func(delta string)) (*Response, error) {
+	chatReq := p.buildRequest(req)
+	chatReq.N = 0
+	chatReq.Stream = true
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := p.client.Client.CreateChatCompletionStream(ctx, chatReq)
+	if isBadRequest(err) {
+		// some openai compatible servers, e.g. older vllm or lm studio, reject stream_options, stream without the usage
+		log.Warn(ctx).Err(err).Str("model", p.Model()).Msg("the stream request is rejected, retry without stream_options")
+		chatReq.StreamOptions = nil
+		stream, err = p.client.Client.CreateChatCompletionStream(ctx, chatReq)
+	}
+
+	if err != nil {
+		return nil, errors.WrapCaller(err)
+	}
+	defer stream.Close()
+
+	var rsp = new(Response)
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.WrapCaller(err)
+		}
+
+		if chunk.Usage != nil {
+			rsp.Usage = Usage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Index != 0 || choice.Delta.Content == "" {
+				continue
+			}
+
+			content.WriteString(choice.Delta.Content)
+			onDelta(choice.Delta.Content)
+		}
+	}
+
+	rsp.Choices = []string{content.String()}
+	return rsp, nil
+}
+
+// CountTokens counts with the tiktoken encoding of the model, o200k_base for the models tiktoken does not know,
+// e.g. of openai compatible apis
+func (p *openaiProvider) CountTokens(ctx context.Context, msgs ...Message) (int, error) {
+	enc, err := tokenizer.ForModel(tokenizer.Model(p.Model()))
+	if err != nil {
+		enc, err = tokenizer.Get(tokenizer.O200kBase)
+		if err != nil {
+			return 0, errors.WrapCaller(err)
+		}
+	}
+
+	var tokens int
+	for _, msg := range msgs {
+		n, err := enc.Count(msg.Content)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to count the tokens of %s", p.Model())
+		}
+		tokens += n
+	}
+	return tokens, nil
+}
+
+// isBadRequest reports whether the api rejected the request with 400 bad request
+func isBadRequest(err error) bool {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.HTTPStatusCode == http.StatusBadRequest
+	case errors.As(err, &reqErr):
+		return reqErr.HTTPStatusCode == http.StatusBadRequest
+	default:
+		return false
+	}
+}
+
+func (p *openaiProvider) buildRequest(req *Request) openai.ChatCompletionRequest {
+	var chatReq = openai.ChatCompletionRequest{Model: p.client.Cfg.Model, N: req.N}
+	if req.Temperature != nil {
+		chatReq.Temperature = *req.Temperature
+	}
+
+	for _, msg := range req.Messages {
+		chatReq.Messages = append(chatReq.Messages, openai.ChatCompletionMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+	return chatReq
+}
@@ -0,0 +1,153 @@
+package llmclient
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+
+	"github.com/pubgo/fastcommit/utils"
+)
+
+const (
+	ProviderOpenai    = "openai"
+	ProviderGemini    = "gemini"
+	ProviderAnthropic = "anthropic"
+	ProviderOllama    = "ollama"
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Provider is the abstraction over the LLM vendors used to generate commit messages
+type Provider interface {
+	// Name returns the provider name, e.g. openai, gemini
+	Name() string
+
+	// Model returns the model the provider talks to
+	Model() string
+
+	// Generate returns the complete response of the request
+	Generate(ctx context.Context, req *Request) (*Response, error)
+
+	// Stream calls onDelta for every generated chunk and returns the aggregated response
+	Stream(ctx context.Context, req *Request, onDelta func(delta string)) (*Response, error)
+
+	// CountTokens returns the number of prompt tokens the messages consume
+	CountTokens(ctx context.Context, msgs ...Message) (int, error)
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type Request struct {
+	Messages    []Message
+	Temperature *float32
+
+	// N is the number of candidates to generate, providers without native support return one
+	N int
+}
+
+// System returns the joined system messages of the request
+func (r *Request) System() string {
+	var parts []string
+	for _, msg := range r.Messages {
+		if msg.Role == RoleSystem {
+			parts = append(parts, msg.Content)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Conversation returns the request messages without the system messages
+func (r *Request) Conversation() []Message {
+	var msgs []Message
+	for _, msg := range r.Messages {
+		if msg.Role != RoleSystem {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add returns the sum of both usages
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+	}
+}
+
+type Response struct {
+	Choices []string `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// Content returns the first choice of the response
+func (r *Response) Content() string {
+	if r == nil || len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0]
+}
+
+type Config struct {
+	// Provider one of openai, gemini, anthropic, ollama, default: openai
+	Provider  string           `yaml:"provider"`
+	Gemini    *GeminiConfig    `yaml:"gemini"`
+	Anthropic *AnthropicConfig `yaml:"anthropic"`
+	Ollama    *OllamaConfig    `yaml:"ollama"`
+}
+
+// New creates the provider selected in config, the openai client is used by default
+func New(cfg *Config, openaiClient *utils.OpenaiClient) (Provider, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+
+	switch name := strings.ToLower(strings.TrimSpace(cfg.Provider)); name {
+	case "", ProviderOpenai:
+		return NewOpenai(openaiClient)
+	case ProviderGemini:
+		return NewGemini(cfg.Gemini)
+	case ProviderAnthropic:
+		return NewAnthropic(cfg.Anthropic)
+	case ProviderOllama:
+		return NewOllama(cfg.Ollama)
+	default:
+		return nil, errors.Errorf("unknown llm provider: %s", name)
+	}
+}
+
+// NewTokenCounter returns the token counter of the diff budget, the provider counts text once and the parts of text
+// are estimated in proportion, utils.CountTokens is the fallback when the provider can not count
+func NewTokenCounter(ctx context.Context, p Provider, text string) utils.TokenCounter {
+	tokens, err := p.CountTokens(ctx, Message{Role: RoleUser, Content: text})
+	if err != nil {
+		log.Warn(ctx).Err(err).Str("provider", p.Name()).Msg("failed to count the tokens, estimate them")
+		return utils.CountTokens
+	}
+	return utils.ScaledTokenCounter(text, tokens)
+}
+
+// estimateTokens approximates the token count for providers without a token counting api
+func estimateTokens(msgs ...Message) int {
+	var tokens int
+	for _, msg := range msgs {
+		tokens += utils.CountTokens(msg.Content)
+	}
+	return tokens
+}
@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/pubgo/funk/v2/log"
+	"github.com/samber/lo"
+)
+
+// Module is a go module of the repository, its tags are prefixed by its directory, e.g. pkg/foo/v1.2.3
+type Module struct {
+	// Path is the module path of go.mod
+	Path string `json:"path"`
+
+	// Dir is the slash separated directory relative to the repository root, empty for the root module
+	Dir string `json:"dir"`
+
+	// nested are the directories of the modules inside of Dir, their files do not belong to this module
+	nested []string
+
+	// sharedPrefix is set when a major version module, e.g. foo/v2, has the tag prefix of this module
+	sharedPrefix bool
+}
+
+var majorSuffixRegexp = regexp.MustCompile(`/v([2-9]|[1-9][0-9]+)$`)
+
+// Name is the directory of the module, . for the root module
+func (m *Module) Name() string { return lo.CoalesceOrEmpty(m.Dir, ".") }
+
+// TagPrefix is the prefix of the tags, the major version directory of a module, e.g. foo/v2, is not part of it
+func (m *Module) TagPrefix() string {
+	dir := m.Dir
+	if suffix := majorSuffixRegexp.FindString(m.Path); suffix != "" && strings.HasSuffix("/"+dir, suffix) {
+		dir = strings.TrimPrefix(strings.TrimSuffix("/"+dir, suffix), "/")
+	}
+	return lo.Ternary(dir == "", "", dir+"/")
+}
+
+// TagName is the tag of the version, e.g. pkg/foo/v1.2.3
+func (m *Module) TagName(ver *semver.Version) string {
+	return m.TagPrefix() + "v" + strings.TrimPrefix(ver.Original(), "v")
+}
+
+// Major is the major version of the module path, 1 without a /vN suffix
+func (m *Module) Major() int {
+	if suffix := majorSuffixRegexp.FindStringSubmatch(m.Path); suffix != nil {
+		return lo.Must1(strconv.Atoi(suffix[1]))
+	}
+	return 1
+}
+
+// Pathspecs are the git pathspecs of the files of the module, the nested modules are excluded,
+// they are relative to the repository root, not to the working directory
+func (m *Module) Pathspecs() []string {
+	specs := []string{":(top)" + m.Dir}
+	for _, dir := range m.nested {
+		specs = append(specs, ":(top,exclude)"+dir)
+	}
+	return specs
+}
+
+// FindModules returns the go modules below root, sorted by directory, the root module first.
+// Hidden directories, vendor and testdata are skipped like the go command does.
+func FindModules(root string) ([]*Module, error) {
+	var modules []*Module
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if name != "go.mod" {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", p)
+		}
+
+		modPath := parseModulePath(data)
+		if modPath == "" {
+			log.Warn().Str("file", p).Msg("go.mod without module path, skip it")
+			return nil
+		}
+
+		dir, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return errors.WrapCaller(err)
+		}
+
+		dir = filepath.ToSlash(dir)
+		modules = append(modules, &Module{Path: modPath, Dir: lo.Ternary(dir == ".", "", dir)})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the go modules of %s", root)
+	}
+
+	slices.SortFunc(modules, func(a, b *Module) int { return strings.Compare(a.Dir, b.Dir) })
+	for _, m := range modules {
+		for _, other := range modules {
+			if other == m {
+				continue
+			}
+
+			if m.Dir == "" || strings.HasPrefix(other.Dir, m.Dir+"/") {
+				m.nested = append(m.nested, other.Dir)
+			}
+
+			if m.Major() == 1 && other.TagPrefix() == m.TagPrefix() {
+				m.sharedPrefix = true
+			}
+		}
+	}
+	return modules, nil
+}
+
+// parseModulePath returns the path of the module directive, empty when there is none
+func parseModulePath(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line, _, _ = strings.Cut(line, "//")
+		rest, ok := strings.CutPrefix(line, "module")
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+
+		rest = strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(rest); err == nil {
+			return unquoted
+		}
+		return rest
+	}
+	return ""
+}
+
+// ModulePlan is the next version of a module
+type ModulePlan struct {
+	Module *Module
+	Plan   *VersionPlan
+
+	// Changed is the number of commits which changed the module since its latest release, all of them for a new module
+	Changed int
+}
+
+// Tags returns the versions of the tags of the module, the tags of a major version module have its major version
+func (m *Module) Tags(ctx context.Context) []*semver.Version {
+	return lo.Filter(GetPrefixGitTags(ctx, m.TagPrefix()), func(item *semver.Version, index int) bool {
+		major := item.Segments()[0]
+		switch {
+		case m.Major() > 1:
+			return major == m.Major()
+		case m.sharedPrefix:
+			return major <= 1
+		default:
+			return true
+		}
+	})
+}
+
+// GetModulePlan plans the next version of the module from the conventional commits which changed its files
+// since its latest release tag, a major bump which would leave the major version of the module path is a minor bump
+func GetModulePlan(ctx context.Context, m *Module) (*ModulePlan, error) {
+	current := GetLatestReleaseTag(m.Tags(ctx))
+
+	var rev = "HEAD"
+	if current != nil {
+		rev = m.TagName(current) + "..HEAD"
+	}
+
+	changed, err := CountCommits(ctx, append([]string{rev, "--"}, m.Pathspecs()...)...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to count the commits of the module %s", m.Name())
+	}
+
+	commits, err := GetConventionalCommits(ctx, append([]string{rev, "--"}, m.Pathspecs()...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := PlanVersion(current, changed, commits)
+	switch major := plan.Next.Segments()[0]; {
+	case current == nil && m.Major() > 1:
+		// the first release of a major version module path
+		plan.Next = semver.Must(semver.NewSemver(fmt.Sprintf("v%d.0.0", m.Major())))
+	case current != nil && major > m.Major():
+		log.Warn().Str("module", m.Path).Str("next", plan.Next.Original()).
+			Msgf("a breaking change of a go module needs the module path %s/v%d, plan a minor release", strings.TrimSuffix(m.Path, majorSuffixRegexp.FindString(m.Path)), major)
+
+		segments := current.Core().Segments()
+		plan.Bump = BumpMinor
+		plan.Next = semver.Must(semver.NewSemver(fmt.Sprintf("v%d.%d.0", segments[0], segments[1]+1)))
+	}
+	return &ModulePlan{Module: m, Plan: plan, Changed: changed}, nil
+}
+
+// GetModulePlans plans the next versions of the modules of the repository, nil when the repository has no go module
+func GetModulePlans(ctx context.Context) ([]*ModulePlan, error) {
+	res, err := RunGit(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the repository root")
+	}
+
+	modules, err := FindModules(strings.TrimSpace(res.Stdout))
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []*ModulePlan
+	for _, m := range modules {
+		plan, err := GetModulePlan(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+func TestFindModules(t *testing.T) {
+	repo := gittest.New(t)
+	for dir, content := range map[string]string{
+		".":            "module example.com/mr\n",
+		"pkg/foo":      "// comment\nmodule \"example.com/mr/pkg/foo\" // quoted\n",
+		"foo/v2":       "module example.com/mr/foo/v2\n",
+		"foo":          "module example.com/mr/foo\n",
+		"vendor/x":     "module example.com/x\n",
+		".git/y":       "module example.com/y\n",
+		"pkg/testdata": "module example.com/testdata\n",
+	} {
+		repo.Write(dir+"/go.mod", content)
+	}
+
+	modules, err := FindModules(repo.Dir)
+	require.NoError(t, err)
+
+	var got []string
+	for _, m := range modules {
+		got = append(got, m.Name()+"="+m.Path+"@"+m.TagPrefix())
+	}
+	require.Equal(t, []string{".=example.com/mr@", "foo=example.com/mr/foo@foo/", "foo/v2=example.com/mr/foo/v2@foo/", "pkg/foo=example.com/mr/pkg/foo@pkg/foo/"}, got)
+
+	assert.Equal(t, []string{":(top)", ":(top,exclude)foo", ":(top,exclude)foo/v2", ":(top,exclude)pkg/foo"}, modules[0].Pathspecs())
+	assert.Equal(t, []string{":(top)foo", ":(top,exclude)foo/v2"}, modules[1].Pathspecs())
+	assert.True(t, modules[1].sharedPrefix, "foo shares the prefix")
+	assert.Equal(t, 2, modules[2].Major())
+}
+
+func TestGetModulePlans(t *testing.T) {
+	ctx := context.Background()
+	repo := gittest.New(t)
+	repo.Commit("go.mod", "module example.com/mr\n", "feat: root")
+	repo.Commit("pkg/foo/go.mod", "module example.com/mr/pkg/foo\n", "feat: foo")
+	repo.Commit("foo/v2/go.mod", "module example.com/mr/foo/v2\n", "feat: foo v2")
+	repo.Git("tag", "v1.0.0")
+	repo.Git("tag", "pkg/foo/v1.1.0")
+	repo.Git("tag", "pkg/foo/bar/v9.0.0")
+	repo.Git("tag", "foo/v2.3.0")
+
+	repo.Commit("pkg/foo/a.go", "fix(foo): a", "fix(foo): a")
+	repo.Commit("foo/v2/b.go", "feat!: b", "feat!: b")
+
+	// the pathspecs are relative to the root, git runs in a nested directory like in the subdirectory of a user
+	for _, dir := range []string{repo.Dir, filepath.Join(repo.Dir, "foo", "v2")} {
+		useGitDir(t, dir)
+		plans, err := GetModulePlans(ctx)
+		require.NoError(t, err)
+
+		var got []string
+		for _, p := range plans {
+			got = append(got, p.Module.TagName(p.Plan.Next)+"/"+strings.Repeat("*", p.Changed))
+		}
+
+		// foo/v2 stays in its major version, the changes of the nested modules are not changes of the root, it keeps its version
+		require.Equal(t, []string{"v1.0.0/", "foo/v2.4.0/*", "pkg/foo/v1.1.1/*"}, got, dir)
+
+		msg, err := GetTagMessage(ctx, "pkg/foo/v1.1.1", plans[2].Module)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(msg, "Release pkg/foo/v1.1.1\n\n### Bug Fixes\n\n- **foo:** a ("), "message = %q", msg)
+		assert.NotContains(t, msg, "b (")
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+var outputFormat = OutputText
+
+// SetOutputFormat sets the format of the command results, json implies the non-interactive mode
+func SetOutputFormat(format string) {
+	outputFormat = format
+	if format == OutputJSON {
+		SetNonInteractive(true)
+	}
+}
+
+// IsJSONOutput reports whether the command results are printed as json to stdout
+func IsJSONOutput() bool { return outputFormat == OutputJSON }
+
+// PrintJSON prints the command result as one json document to stdout, logs go to stderr
+func PrintJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return errors.Wrap(enc.Encode(v), "failed to encode json output")
+}
+
+// Stdout is where the messages for the user go, stderr in json output, so that stdout stays one json document
+func Stdout() io.Writer {
+	if IsJSONOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
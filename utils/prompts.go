@@ -1,8 +1,13 @@
 package utils
 
 import (
-	"fmt"
+	"bytes"
+	"os"
 	"strings"
+	"text/template"
+
+	"github.com/pubgo/funk/v2/assert"
+	"github.com/pubgo/funk/v2/errors"
 )
 
 type CommitType string
@@ -10,55 +15,94 @@ type CommitType string
 const (
 	EmptyCommitType        CommitType = ""
 	ConventionalCommitType CommitType = "conventional"
+	AngularCommitType      CommitType = "angular"
+	GitmojiCommitType      CommitType = "gitmoji"
 )
 
-const conventionalData = `
-Choose a type from the type-to-description JSON below that best describes the git diff:
-{
-  "docs": "Documentation only changes",
-  "style": "Changes that do not affect the meaning of the code (white-space, formatting, missing semi-colons, etc)",
-  "refactor": "A code change that neither fixes a bug nor adds a feature",
-  "perf": "A code change that improves performance",
-  "test": "Adding missing tests or correcting existing tests",
-  "build": "Changes that affect the build system or external dependencies",
-  "ci": "Changes to our CI configuration files and scripts",
-  "chore": "Other changes that don't modify src or test files",
-  "revert": "Reverts a previous commit",
-  "feat": "A new feature",
-  "fix": "A bug fix"
-}`
-
-var commitTypeFormats = map[CommitType]string{
-	EmptyCommitType:        "<commit message>",
-	ConventionalCommitType: "<type>(<optional scope>): <commit message>",
+// DefaultPromptTemplate is used when neither the repo nor the user config dir provides a prompt.tmpl
+const DefaultPromptTemplate = `Generate a concise git commit message written in present tense for the following code diff with the given specifications below:
+Message language: {{.Locale}}
+{{if .Body}}The commit subject{{else}}Commit message{{end}} must be a maximum of {{.MaxLength}} characters.
+Exclude anything unnecessary such as translation. Your entire response will be passed directly into git commit.
+{{- with .TypeDescription}}
+{{.}}
+{{- end}}
+{{- if .Body}}
+After the subject add a blank line and a body wrapped at 72 characters which explains what changed and why, not how.
+Only when they apply, end with trailers such as "Refs: #<issue>" or "BREAKING CHANGE: <description>".
+{{- end}}
+The output response must be in format:
+{{.Format}}
+{{- if .Body}}
+
+<body>
+
+<optional trailers>
+{{- end}}`
+
+// PromptData is the data available in prompt templates
+type PromptData struct {
+	Branch          string
+	Files           []string
+	RecentLog       string
+	Locale          string
+	MaxLength       int
+	CommitType      CommitType
+	TypeDescription string
+	Format          string
+	Scopes          []string
+
+	// Body asks for a subject, a body and trailers instead of a single line
+	Body bool
 }
 
-func specifyCommitFormat(commitType CommitType) string {
-	return fmt.Sprintf("The output response must be in format:\n%s", commitTypeFormats[commitType])
+func NewPromptData(locale string, maxLength int, scheme *CommitScheme) *PromptData {
+	return &PromptData{
+		Locale:          locale,
+		MaxLength:       maxLength,
+		CommitType:      scheme.Name,
+		TypeDescription: scheme.TypeDescription(),
+		Format:          scheme.SubjectFormat(),
+		Scopes:          scheme.Scopes,
+	}
 }
 
-var commitTypes = map[CommitType]string{
-	ConventionalCommitType: conventionalData,
-	EmptyCommitType:        "",
+var promptFuncs = template.FuncMap{
+	"join": func(elems []string, sep string) string { return strings.Join(elems, sep) },
 }
 
-func GeneratePrompt(locale string, maxLength int, commitType CommitType) string {
-	promptParts := []string{
-		"Generate a concise git commit message written in present tense for the following code diff with the given specifications below:",
-		fmt.Sprintf("Message language: %s", locale),
-		fmt.Sprintf("Commit message must be a maximum of %d characters.", maxLength),
-		"Exclude anything unnecessary such as translation. Your entire response will be passed directly into git commit.",
-		commitTypes[commitType],
-		specifyCommitFormat(commitType),
+// LoadPromptTemplate returns the content of the first existing template path, or the default template
+func LoadPromptTemplate(paths ...string) (tmpl string, tmplPath string) {
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err == nil && len(bytes.TrimSpace(data)) > 0 {
+			return string(data), p
+		}
 	}
+	return DefaultPromptTemplate, ""
+}
 
-	// Filter out empty strings
-	var filteredParts []string
-	for _, part := range promptParts {
-		if part != "" {
-			filteredParts = append(filteredParts, part)
-		}
+// RenderPrompt executes the prompt template with the data
+func RenderPrompt(tmpl string, data *PromptData) (string, error) {
+	t, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse prompt template")
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(err, "failed to render prompt template")
 	}
+	return strings.TrimSpace(buf.String()), nil
+}
 
-	return strings.Join(filteredParts, "\n")
+func GeneratePrompt(locale string, maxLength int, commitType CommitType) string {
+	scheme := assert.Must1(GetCommitScheme(commitType))
+	return assert.Must1(RenderPrompt(DefaultPromptTemplate, NewPromptData(locale, maxLength, scheme)))
 }
+
+const summarizeDiffPrompt = `Summarize the following git diff of a single file in one or two sentences written in present tense.
+Focus on what changed and why it matters, do not mention line numbers. Your entire response is used as input for generating a commit message.`
+
+// GenerateSummaryPrompt returns the prompt used to summarize the diff of a file in map-reduce mode
+func GenerateSummaryPrompt() string { return summarizeDiffPrompt }
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	data := NewPromptData("zh", 72, builtinSchemes[ConventionalCommitType])
+	data.Branch = "feat/prompt"
+	data.Files = []string{"main.go", "go.mod"}
+
+	prompt, err := RenderPrompt("{{.Locale}} {{.MaxLength}} {{.Branch}} {{join .Files \",\"}} {{.Format}}", data)
+	require.NoError(t, err)
+	assert.Equal(t, "zh 72 feat/prompt main.go,go.mod <type>(<optional scope>): <commit message>", prompt)
+
+	prompt = GeneratePrompt("en", 50, ConventionalCommitType)
+	assert.Contains(t, prompt, "maximum of 50 characters")
+	assert.Contains(t, prompt, `"feat": "A new feature"`)
+
+	_, err = RenderPrompt("{{.Unknown}}", data)
+	assert.Error(t, err)
+}
+
+func TestLoadPromptTemplate(t *testing.T) {
+	dir := t.TempDir()
+	repoPath := filepath.Join(dir, "repo.tmpl")
+	userPath := filepath.Join(dir, "user.tmpl")
+	require.NoError(t, os.WriteFile(userPath, []byte("user"), 0o644))
+
+	tmpl, p := LoadPromptTemplate(repoPath, userPath)
+	assert.Equal(t, "user", tmpl)
+	assert.Equal(t, userPath, p)
+
+	tmpl, p = LoadPromptTemplate(repoPath)
+	assert.Equal(t, DefaultPromptTemplate, tmpl)
+	assert.Empty(t, p)
+}
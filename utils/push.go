@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+// PushStatus is the outcome of pushing a ref, it decides the recovery path of the commands
+type PushStatus string
+
+const (
+	PushOK                     PushStatus = "ok"
+	PushUpToDate               PushStatus = "up-to-date"
+	PushRejectedNonFastForward PushStatus = "non-fast-forward"
+	PushStaleLease             PushStatus = "stale-lease"
+	PushTagExists              PushStatus = "tag-exists"
+	PushHookDeclined           PushStatus = "hook-declined"
+	PushRejected               PushStatus = "rejected"
+	PushAuthError              PushStatus = "auth-error"
+	PushError                  PushStatus = "error"
+)
+
+// PushRef is a ref line of git push --porcelain
+type PushRef struct {
+	// Flag is ' ' fast-forward, '+' forced update, '-' deleted, '*' new ref, '!' rejected, '=' up to date
+	Flag    byte
+	From    string
+	To      string
+	Summary string
+	Reason  string
+	Status  PushStatus
+}
+
+// PushResult is the parsed result of git push --porcelain
+type PushResult struct {
+	Remote string
+	Refs   []*PushRef
+
+	// Output is stdout and stderr of git, for the log
+	Output string
+
+	// Err is set when git failed before any ref was pushed, e.g. the remote is not reachable
+	Err error
+
+	status PushStatus
+}
+
+// Status is the first status which is not ok of the refs, or the status of the failure before the refs were pushed
+func (r *PushResult) Status() PushStatus {
+	if r.status != "" {
+		return r.status
+	}
+
+	for _, ref := range r.Refs {
+		if ref.Status != PushOK && ref.Status != PushUpToDate {
+			return ref.Status
+		}
+	}
+	return PushOK
+}
+
+// OK reports whether every ref was pushed or already up to date
+func (r *PushResult) OK() bool { return r.Status() == PushOK }
+
+// AsError describes why the push failed, it is nil when every ref was pushed
+func (r *PushResult) AsError() error {
+	status := r.Status()
+	switch {
+	case status == PushOK:
+		return nil
+	case r.Err != nil:
+		return errors.Wrapf(r.Err, "git push failed: %s", status)
+	default:
+		return errors.Errorf("git push failed: %s\n%s", status, r.Output)
+	}
+}
+
+// NeedPull reports whether the remote has commits which have to be pulled before the push can succeed
+func (r *PushResult) NeedPull() bool {
+	status := r.Status()
+	return status == PushRejectedNonFastForward || status == PushStaleLease
+}
+
+var (
+	pushReasonRegexp = regexp.MustCompile(`\(([^()]*)\)\s*$`)
+	pushAuthErrors   = []string{
+		"authentication failed", "permission denied", "could not read username", "could not read password",
+		"invalid username or password", "access denied", "returned error: 403", "returned error: 401",
+	}
+)
+
+// ParsePushResult parses the output of git push --porcelain, err is the error of RunGit
+func ParsePushResult(res *GitResult, err error) *PushResult {
+	if res == nil {
+		res = new(GitResult)
+	}
+
+	var result = &PushResult{Output: res.Output()}
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "To "):
+			result.Remote = strings.TrimPrefix(line, "To ")
+		case line == "Done" || line == "":
+		default:
+			if ref := parsePushRef(line); ref != nil {
+				result.Refs = append(result.Refs, ref)
+			}
+		}
+	}
+
+	if err == nil || len(result.Refs) > 0 {
+		return result
+	}
+
+	// git failed before it pushed any ref
+	result.Err = err
+	result.status = PushError
+	stderr := strings.ToLower(res.Stderr)
+	for _, msg := range pushAuthErrors {
+		if strings.Contains(stderr, msg) {
+			result.status = PushAuthError
+			break
+		}
+	}
+	return result
+}
+
+func parsePushRef(line string) *PushRef {
+	fields := strings.SplitN(line, "\t", 3)
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return nil
+	}
+
+	var ref = &PushRef{Flag: fields[0][0]}
+	ref.From, ref.To, _ = strings.Cut(fields[1], ":")
+	if len(fields) == 3 {
+		ref.Summary = fields[2]
+		if m := pushReasonRegexp.FindStringSubmatch(fields[2]); m != nil {
+			ref.Reason = m[1]
+		}
+	}
+
+	switch ref.Flag {
+	case '=':
+		ref.Status = PushUpToDate
+	case '!':
+		ref.Status = pushRejectStatus(ref)
+	default:
+		ref.Status = PushOK
+	}
+	return ref
+}
+
+func pushRejectStatus(ref *PushRef) PushStatus {
+	switch {
+	case ref.Reason == "stale info":
+		return PushStaleLease
+	case ref.Reason == "already exists" || strings.HasPrefix(ref.To, "refs/tags/") && ref.Reason == "would clobber existing tag":
+		return PushTagExists
+	case ref.Reason == "non-fast-forward" || ref.Reason == "fetch first":
+		return PushRejectedNonFastForward
+	case strings.Contains(ref.Reason, "hook declined") || strings.HasPrefix(ref.Summary, "[remote rejected]"):
+		return PushHookDeclined
+	default:
+		return PushRejected
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+func TestParsePushResult(t *testing.T) {
+	cases := []struct {
+		name   string
+		res    *GitResult
+		err    error
+		status PushStatus
+	}{
+		{
+			name:   "ok",
+			res:    &GitResult{Stdout: "To github.com:pubgo/fastcommit.git\n \trefs/heads/main:refs/heads/main\t3f1a..9b2c\nDone\n"},
+			status: PushOK,
+		},
+		{
+			name:   "up to date",
+			res:    &GitResult{Stdout: "To origin\n=\trefs/heads/main:refs/heads/main\t[up to date]\nDone\n"},
+			status: PushOK,
+		},
+		{
+			name:   "non-fast-forward",
+			res:    &GitResult{Stdout: "To origin\n!\trefs/heads/main:refs/heads/main\t[rejected] (fetch first)\nDone\n", ExitCode: 1},
+			err:    errors.New("exit status 1"),
+			status: PushRejectedNonFastForward,
+		},
+		{
+			name:   "stale lease",
+			res:    &GitResult{Stdout: "To origin\n!\trefs/heads/main:refs/heads/main\t[rejected] (stale info)\nDone\n", ExitCode: 1},
+			err:    errors.New("exit status 1"),
+			status: PushStaleLease,
+		},
+		{
+			name:   "tag exists",
+			res:    &GitResult{Stdout: "To origin\n!\trefs/tags/v1.0.0:refs/tags/v1.0.0\t[rejected] (already exists)\nDone\n", ExitCode: 1},
+			err:    errors.New("exit status 1"),
+			status: PushTagExists,
+		},
+		{
+			name:   "hook declined",
+			res:    &GitResult{Stdout: "To origin\n!\trefs/heads/main:refs/heads/main\t[remote rejected] (pre-receive hook declined)\nDone\n", ExitCode: 1},
+			err:    errors.New("exit status 1"),
+			status: PushHookDeclined,
+		},
+		{
+			name:   "auth error",
+			res:    &GitResult{Stderr: "remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/pubgo/fastcommit.git/'", ExitCode: 128},
+			err:    errors.New("exit status 128"),
+			status: PushAuthError,
+		},
+		{
+			name:   "no remote",
+			res:    &GitResult{Stderr: "fatal: 'origin' does not appear to be a git repository", ExitCode: 128},
+			err:    errors.New("exit status 128"),
+			status: PushError,
+		},
+	}
+
+	for _, c := range cases {
+		res := ParsePushResult(c.res, c.err)
+		assert.Equal(t, c.status, res.Status(), c.name)
+		assert.Equal(t, c.status == PushOK, res.OK(), c.name)
+		assert.Equal(t, res.OK(), res.AsError() == nil, c.name)
+	}
+}
+
+func TestGitPushRejected(t *testing.T) {
+	ctx := context.Background()
+	remote := gittest.NewBare(t)
+	local, other := remote.Clone(), remote.Clone()
+
+	other.Commit("a.txt", "other", "other")
+	other.Git("push", "-q", "origin", "main")
+	other.Git("tag", "v1.0.0")
+	other.Git("push", "-q", "origin", "v1.0.0")
+
+	useGitDir(t, local.Dir)
+	local.Commit("a.txt", "local", "local")
+	res := GitPush(ctx, "origin", "main")
+	assert.True(t, res.NeedPull(), "push status = %q\n%s", res.Status(), res.Output)
+
+	res = GitPushTag(ctx, "v1.0.0", nil)
+	assert.Equal(t, PushTagExists, res.Status(), res.Output)
+
+	local.Git("checkout", "-q", "-b", "feat")
+	res = GitPush(ctx, "origin", "feat")
+	assert.True(t, res.OK(), "push status = %q\n%s", res.Status(), res.Output)
+	if assert.Len(t, res.Refs, 1) {
+		assert.Equal(t, byte('*'), res.Refs[0].Flag)
+	}
+}
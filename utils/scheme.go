@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// CommitSchemeType is a commit type of a scheme, gitmoji types are identified by their emoji
+type CommitSchemeType struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Emoji       string `yaml:"emoji"`
+}
+
+// CommitScheme describes the allowed commit types and scopes, the prompt and the validator are derived from it
+type CommitScheme struct {
+	Name   CommitType          `yaml:"name"`
+	Types  []*CommitSchemeType `yaml:"types"`
+	Scopes []string            `yaml:"scopes"`
+
+	// Format is the format of the subject shown to the llm, it is derived from the types when empty
+	Format string `yaml:"format"`
+}
+
+var conventionalTypes = []*CommitSchemeType{
+	{Name: "docs", Description: "Documentation only changes"},
+	{Name: "style", Description: "Changes that do not affect the meaning of the code (white-space, formatting, missing semi-colons, etc)"},
+	{Name: "refactor", Description: "A code change that neither fixes a bug nor adds a feature"},
+	{Name: "perf", Description: "A code change that improves performance"},
+	{Name: "test", Description: "Adding missing tests or correcting existing tests"},
+	{Name: "build", Description: "Changes that affect the build system or external dependencies"},
+	{Name: "ci", Description: "Changes to our CI configuration files and scripts"},
+	{Name: "chore", Description: "Other changes that don't modify src or test files"},
+	{Name: "revert", Description: "Reverts a previous commit"},
+	{Name: "feat", Description: "A new feature"},
+	{Name: "fix", Description: "A bug fix"},
+}
+
+var angularTypes = []*CommitSchemeType{
+	{Name: "build", Description: "Changes that affect the build system or external dependencies (example scopes: gulp, broccoli, npm)"},
+	{Name: "ci", Description: "Changes to our CI configuration files and scripts (examples: CircleCi, SauceLabs)"},
+	{Name: "docs", Description: "Documentation only changes"},
+	{Name: "feat", Description: "A new feature"},
+	{Name: "fix", Description: "A bug fix"},
+	{Name: "perf", Description: "A code change that improves performance"},
+	{Name: "refactor", Description: "A code change that neither fixes a bug nor adds a feature"},
+	{Name: "test", Description: "Adding missing tests or correcting existing tests"},
+}
+
+var gitmojiTypes = []*CommitSchemeType{
+	{Emoji: "🎨", Description: "Improve structure / format of the code"},
+	{Emoji: "⚡️", Description: "Improve performance"},
+	{Emoji: "🔥", Description: "Remove code or files"},
+	{Emoji: "🐛", Description: "Fix a bug"},
+	{Emoji: "🚑️", Description: "Critical hotfix"},
+	{Emoji: "✨", Description: "Introduce new features"},
+	{Emoji: "📝", Description: "Add or update documentation"},
+	{Emoji: "🚀", Description: "Deploy stuff"},
+	{Emoji: "💄", Description: "Add or update the UI and style files"},
+	{Emoji: "✅", Description: "Add, update, or pass tests"},
+	{Emoji: "🔒️", Description: "Fix security or privacy issues"},
+	{Emoji: "🔖", Description: "Release / Version tags"},
+	{Emoji: "🚧", Description: "Work in progress"},
+	{Emoji: "💚", Description: "Fix CI Build"},
+	{Emoji: "⬆️", Description: "Upgrade dependencies"},
+	{Emoji: "⬇️", Description: "Downgrade dependencies"},
+	{Emoji: "👷", Description: "Add or update CI build system"},
+	{Emoji: "♻️", Description: "Refactor code"},
+	{Emoji: "➕", Description: "Add a dependency"},
+	{Emoji: "➖", Description: "Remove a dependency"},
+	{Emoji: "🔧", Description: "Add or update configuration files"},
+	{Emoji: "🌐", Description: "Internationalization and localization"},
+	{Emoji: "✏️", Description: "Fix typos"},
+	{Emoji: "⏪️", Description: "Revert changes"},
+	{Emoji: "🔀", Description: "Merge branches"},
+	{Emoji: "💥", Description: "Introduce breaking changes"},
+	{Emoji: "🗑️", Description: "Deprecate code that needs to be cleaned up"},
+}
+
+var builtinSchemes = map[CommitType]*CommitScheme{
+	EmptyCommitType:        {Name: EmptyCommitType},
+	ConventionalCommitType: {Name: ConventionalCommitType, Types: conventionalTypes},
+	AngularCommitType:      {Name: AngularCommitType, Types: angularTypes},
+	GitmojiCommitType:      {Name: GitmojiCommitType, Types: gitmojiTypes},
+}
+
+// GetCommitScheme returns the custom scheme of the name, or the builtin one
+func GetCommitScheme(name CommitType, custom ...*CommitScheme) (*CommitScheme, error) {
+	for _, s := range custom {
+		if s != nil && s.Name == name {
+			return s, nil
+		}
+	}
+
+	if s, ok := builtinSchemes[name]; ok {
+		return s, nil
+	}
+
+	return nil, errors.Errorf("unknown commit scheme %q, builtin schemes: conventional, angular, gitmoji", name)
+}
+
+// ResolveCommitScheme returns the scheme of the repository when it declares one, otherwise the scheme of the name
+func ResolveCommitScheme(repoSchemePath string, name CommitType, custom ...*CommitScheme) (*CommitScheme, error) {
+	scheme, err := LoadCommitScheme(repoSchemePath)
+	if err != nil || scheme != nil {
+		return scheme, err
+	}
+	return GetCommitScheme(name, custom...)
+}
+
+// LoadCommitScheme reads a custom scheme from a yaml file, a missing file returns nil
+func LoadCommitScheme(schemePath string) (*CommitScheme, error) {
+	data, err := os.ReadFile(schemePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read commit scheme %s", schemePath)
+	}
+
+	var scheme CommitScheme
+	if err := yaml.Unmarshal(data, &scheme); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse commit scheme %s", schemePath)
+	}
+
+	if scheme.Name == "" {
+		scheme.Name = "custom"
+	}
+	return &scheme, nil
+}
+
+// IsEmoji reports whether the types of the scheme are identified by their emoji
+func (s *CommitScheme) IsEmoji() bool {
+	return len(s.Types) > 0 && !slices.ContainsFunc(s.Types, func(t *CommitSchemeType) bool { return t.Name != "" })
+}
+
+// SubjectFormat is the format of the subject shown to the llm
+func (s *CommitScheme) SubjectFormat() string {
+	switch {
+	case s.Format != "":
+		return s.Format
+	case len(s.Types) == 0:
+		return "<commit message>"
+	case s.IsEmoji():
+		return "<emoji> <commit message>"
+	default:
+		return "<type>(<optional scope>): <commit message>"
+	}
+}
+
+// TypeDescription is the part of the prompt which lists the types and scopes
+func (s *CommitScheme) TypeDescription() string {
+	if len(s.Types) == 0 {
+		return ""
+	}
+
+	var what, key = "type", func(t *CommitSchemeType) string { return t.Name }
+	if s.IsEmoji() {
+		what, key = "emoji", func(t *CommitSchemeType) string { return t.Emoji }
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Choose a %s from the %s-to-description JSON below that best describes the git diff:\n{\n", what, what)
+	for i, t := range s.Types {
+		desc := t.Description
+		if t.Emoji != "" && !s.IsEmoji() {
+			desc = t.Emoji + " " + desc
+		}
+
+		fmt.Fprintf(&b, "  %s: %s", strconv.Quote(key(t)), strconv.Quote(desc))
+		if i < len(s.Types)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+
+	if len(s.Scopes) > 0 {
+		fmt.Fprintf(&b, "\nThe scope is optional and must be one of: %s", strings.Join(s.Scopes, ", "))
+	}
+	return b.String()
+}
+
+var subjectRegexp = regexp.MustCompile(`^(?:(\S+) )?([A-Za-z][\w-]*)(?:\(([^()]+)\))?(!)?: (.+)$`)
+
+// Validate checks the subject line of a commit message against the scheme
+func (s *CommitScheme) Validate(subject string) error {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return errors.New("the subject is empty")
+	}
+
+	if len(s.Types) == 0 {
+		return nil
+	}
+
+	if s.IsEmoji() {
+		for _, t := range s.Types {
+			if rest, ok := strings.CutPrefix(stripVariation(subject), stripVariation(t.Emoji)); ok && strings.TrimSpace(rest) != "" {
+				return nil
+			}
+		}
+		return errors.Errorf("the subject %q must start with one of the emoji of the %s scheme", subject, s.Name)
+	}
+
+	m := subjectRegexp.FindStringSubmatch(subject)
+	if m == nil {
+		return errors.Errorf("the subject %q does not match the format %q", subject, s.SubjectFormat())
+	}
+
+	emoji, typ, scope := m[1], m[2], m[3]
+	idx := slices.IndexFunc(s.Types, func(t *CommitSchemeType) bool { return t.Name == typ })
+	if idx < 0 {
+		return errors.Errorf("unknown commit type %q, allowed types: %s", typ, strings.Join(s.TypeNames(), ", "))
+	}
+
+	if emoji != "" && s.Types[idx].Emoji != "" && stripVariation(emoji) != stripVariation(s.Types[idx].Emoji) {
+		return errors.Errorf("the emoji of the commit type %q must be %s", typ, s.Types[idx].Emoji)
+	}
+
+	if scope != "" && len(s.Scopes) > 0 && !slices.Contains(s.Scopes, scope) {
+		return errors.Errorf("unknown commit scope %q, allowed scopes: %s", scope, strings.Join(s.Scopes, ", "))
+	}
+	return nil
+}
+
+// TypeNames returns the names of the types, or the emoji of the gitmoji types
+func (s *CommitScheme) TypeNames() []string {
+	var names []string
+	for _, t := range s.Types {
+		names = append(names, lo.CoalesceOrEmpty(t.Name, t.Emoji))
+	}
+	return names
+}
+
+// stripVariation removes the emoji variation selector, llms often drop or add it
+func stripVariation(s string) string { return strings.ReplaceAll(s, "\uFE0F", "") }
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitSchemeValidate(t *testing.T) {
+	conventional, _ := GetCommitScheme(ConventionalCommitType)
+	angular, _ := GetCommitScheme(AngularCommitType)
+	gitmoji, _ := GetCommitScheme(GitmojiCommitType)
+	custom := &CommitScheme{
+		Name:   "team",
+		Types:  []*CommitSchemeType{{Name: "feat", Emoji: "✨"}, {Name: "fix", Emoji: "🐛"}},
+		Scopes: []string{"api", "cli"},
+	}
+
+	cases := []struct {
+		scheme  *CommitScheme
+		subject string
+		valid   bool
+	}{
+		{conventional, "feat(llm): add streaming", true},
+		{conventional, "chore!: drop go1.22", true},
+		{conventional, "add streaming", false},
+		{conventional, "feature: add streaming", false},
+		{angular, "chore: bump deps", false},
+		{angular, "fix(core): handle nil", true},
+		{gitmoji, "✨ add streaming", true},
+		{gitmoji, "⚡ speed up the diff parser", true},
+		{gitmoji, "feat: add streaming", false},
+		{custom, "✨ feat(api): add streaming", true},
+		{custom, "feat(web): add streaming", false},
+		{custom, "🐛 feat: add streaming", false},
+		{&CommitScheme{}, "anything goes", true},
+	}
+
+	for _, c := range cases {
+		err := c.scheme.Validate(c.subject)
+		assert.Equal(t, c.valid, err == nil, "%s: %q, err=%v", c.scheme.Name, c.subject, err)
+	}
+
+	_, err := GetCommitScheme("unknown")
+	assert.Error(t, err)
+}
+
+func TestCommitSchemePrompt(t *testing.T) {
+	gitmoji, _ := GetCommitScheme(GitmojiCommitType)
+	assert.Contains(t, gitmoji.TypeDescription(), `"✨": "Introduce new features"`)
+	assert.Equal(t, "<emoji> <commit message>", gitmoji.SubjectFormat())
+
+	schemePath := filepath.Join(t.TempDir(), "scheme.yaml")
+	data := "types:\n  - name: feat\n    description: A new feature\nscopes: [api]\n"
+	require.NoError(t, os.WriteFile(schemePath, []byte(data), 0o644))
+
+	scheme, err := ResolveCommitScheme(schemePath, ConventionalCommitType)
+	require.NoError(t, err)
+	assert.Contains(t, scheme.TypeDescription(), `"feat": "A new feature"`)
+	assert.Contains(t, scheme.TypeDescription(), "must be one of: api")
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pubgo/funk/v2/errors"
+)
+
+// FileState is a change of a file in git status --porcelain=v2
+type FileState struct {
+	Path string
+
+	// OrigPath is the source of a rename or copy
+	OrigPath string
+
+	// Staged and Unstaged are the X and Y status codes, '.' is unchanged, e.g. 'M', 'A', 'D', 'R', 'C' or 'U'
+	Staged   byte
+	Unstaged byte
+
+	Untracked  bool
+	Conflicted bool
+}
+
+// RepoStatus is the parsed output of git status --porcelain=v2 --branch, together with the operation in progress
+type RepoStatus struct {
+	// Commit is empty before the first commit
+	Commit string
+
+	// Branch is empty when the head is detached
+	Branch string
+
+	// Upstream is empty when the branch does not track a remote branch
+	Upstream string
+	Ahead    int
+	Behind   int
+
+	Merging       bool
+	Rebasing      bool
+	CherryPicking bool
+	Reverting     bool
+
+	Files []*FileState
+}
+
+// IsDirty reports whether the work tree or the index has changes, untracked files included
+func (s *RepoStatus) IsDirty() bool { return len(s.Files) > 0 }
+
+// NeedPush reports whether the branch has local commits which the upstream does not have, and is not behind it
+func (s *RepoStatus) NeedPush() bool { return s.Upstream != "" && s.Ahead > 0 && s.Behind == 0 }
+
+// Diverged reports whether the branch and its upstream both have commits the other one does not have
+func (s *RepoStatus) Diverged() bool { return s.Upstream != "" && s.Ahead > 0 && s.Behind > 0 }
+
+// InProgress reports whether a merge, rebase, cherry-pick or revert waits to be concluded
+func (s *RepoStatus) InProgress() bool {
+	return s.Merging || s.Rebasing || s.CherryPicking || s.Reverting
+}
+
+// Conflicts returns the paths of the unmerged files
+func (s *RepoStatus) Conflicts() []string {
+	var paths []string
+	for _, f := range s.Files {
+		if f.Conflicted {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+// GetRepoStatus runs git status --porcelain=v2 --branch, the output does not depend on the locale of git
+func GetRepoStatus(ctx context.Context) (*RepoStatus, error) {
+	res, err := RunGit(ctx, "status", "--porcelain=v2", "--branch", "-z")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get git status")
+	}
+
+	status, err := ParseRepoStatus(res.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = RunGit(ctx, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get git dir")
+	}
+
+	gitDir := strings.TrimSpace(res.Stdout)
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(gitDir, name))
+		return err == nil
+	}
+	status.Merging = exists("MERGE_HEAD")
+	status.Rebasing = exists("rebase-merge") || exists("rebase-apply")
+	status.CherryPicking = exists("CHERRY_PICK_HEAD")
+	status.Reverting = exists("REVERT_HEAD")
+	return status, nil
+}
+
+// ParseRepoStatus parses the output of git status --porcelain=v2 --branch -z
+func ParseRepoStatus(output string) (*RepoStatus, error) {
+	var status = new(RepoStatus)
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry == "" {
+			continue
+		}
+
+		switch entry[0] {
+		case '#':
+			if err := status.parseHeader(entry); err != nil {
+				return nil, err
+			}
+		case '1':
+			fields := strings.SplitN(entry, " ", 9)
+			if len(fields) != 9 {
+				return nil, errors.Errorf("invalid git status entry %q", entry)
+			}
+			status.Files = append(status.Files, newFileState(fields[1], fields[8]))
+		case '2':
+			// the source of the rename is the next entry
+			fields := strings.SplitN(entry, " ", 10)
+			if len(fields) != 10 || i+1 >= len(entries) {
+				return nil, errors.Errorf("invalid git status entry %q", entry)
+			}
+			file := newFileState(fields[1], fields[9])
+			file.OrigPath = entries[i+1]
+			status.Files = append(status.Files, file)
+			i++
+		case 'u':
+			fields := strings.SplitN(entry, " ", 11)
+			if len(fields) != 11 {
+				return nil, errors.Errorf("invalid git status entry %q", entry)
+			}
+			file := newFileState(fields[1], fields[10])
+			file.Conflicted = true
+			status.Files = append(status.Files, file)
+		case '?':
+			status.Files = append(status.Files, &FileState{Path: entry[2:], Staged: '?', Unstaged: '?', Untracked: true})
+		case '!':
+			// ignored files are only listed with --ignored
+		default:
+			return nil, errors.Errorf("unknown git status entry %q", entry)
+		}
+	}
+	return status, nil
+}
+
+func (s *RepoStatus) parseHeader(line string) error {
+	key, value, _ := strings.Cut(strings.TrimPrefix(line, "# "), " ")
+	switch key {
+	case "branch.oid":
+		if value != "(initial)" {
+			s.Commit = value
+		}
+	case "branch.head":
+		if value != "(detached)" {
+			s.Branch = value
+		}
+	case "branch.upstream":
+		s.Upstream = value
+	case "branch.ab":
+		ahead, behind, ok := strings.Cut(value, " ")
+		if !ok {
+			return errors.Errorf("invalid git status header %q", line)
+		}
+
+		var err error
+		if s.Ahead, err = strconv.Atoi(strings.TrimPrefix(ahead, "+")); err != nil {
+			return errors.Wrapf(err, "invalid git status header %q", line)
+		}
+
+		if s.Behind, err = strconv.Atoi(strings.TrimPrefix(behind, "-")); err != nil {
+			return errors.Wrapf(err, "invalid git status header %q", line)
+		}
+	}
+	return nil
+}
+
+func newFileState(xy string, path string) *FileState {
+	return &FileState{Path: path, Staged: xy[0], Unstaged: xy[1]}
+}
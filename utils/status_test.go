@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+func TestParseRepoStatus(t *testing.T) {
+	output := strings.Join([]string{
+		"# branch.oid 5f0c5e2b1c7d9f1b2a3c4d5e6f708192a3b4c5d6",
+		"# branch.head feat/status",
+		"# branch.upstream origin/feat/status",
+		"# branch.ab +2 -1",
+		"1 .M N... 100644 100644 100644 3f1a 3f1a utils/status.go",
+		"1 A. N... 000000 100644 100644 0000 9b2c docs/a file.md",
+		"2 R. N... 100644 100644 100644 7c3d 7c3d R100 utils/new.go",
+		"utils/old.go",
+		"u UU N... 100644 100644 100644 100644 a1 b2 c3 README.md",
+		"? tmp.txt",
+		"",
+	}, "\x00")
+
+	status, err := ParseRepoStatus(output)
+	require.NoError(t, err)
+
+	assert.Equal(t, "feat/status", status.Branch)
+	assert.Equal(t, "origin/feat/status", status.Upstream)
+	assert.Equal(t, 2, status.Ahead)
+	assert.Equal(t, 1, status.Behind)
+	assert.True(t, status.Diverged())
+	assert.False(t, status.NeedPush())
+	assert.True(t, status.IsDirty())
+
+	var paths []string
+	for _, f := range status.Files {
+		paths = append(paths, string([]byte{f.Staged, f.Unstaged})+" "+f.Path)
+	}
+	assert.Equal(t, []string{".M utils/status.go", "A. docs/a file.md", "R. utils/new.go", "UU README.md", "?? tmp.txt"}, paths)
+	assert.Equal(t, "utils/old.go", status.Files[2].OrigPath)
+	assert.Equal(t, []string{"README.md"}, status.Conflicts())
+
+	initial, err := ParseRepoStatus("# branch.oid (initial)\x00# branch.head (detached)\x00")
+	require.NoError(t, err)
+	assert.Empty(t, initial.Commit)
+	assert.Empty(t, initial.Branch)
+	assert.False(t, initial.IsDirty())
+
+	_, err = ParseRepoStatus("1 .M N...\x00")
+	assert.Error(t, err, "a truncated entry")
+}
+
+func TestGetRepoStatusMerging(t *testing.T) {
+	repo := gittest.New(t)
+	useGitDir(t, repo.Dir)
+
+	repo.Commit("a.txt", "base\n", "base")
+	repo.Git("checkout", "-q", "-b", "feat")
+	repo.Commit("a.txt", "feat\n", "feat")
+	repo.Git("checkout", "-q", "main")
+	repo.Commit("a.txt", "main\n", "main")
+	_, err := repo.Run("merge", "feat")
+	require.Error(t, err, "the merge conflicts")
+
+	status, err := GetRepoStatus(context.Background())
+	require.NoError(t, err)
+	assert.True(t, status.Merging)
+	assert.True(t, status.InProgress())
+	assert.Equal(t, "main", status.Branch)
+	assert.Equal(t, []string{"a.txt"}, status.Conflicts())
+}
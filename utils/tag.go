@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// TagConfig is how the release tags are created, set it per repo in the local env file .git/fastcommit.env
+type TagConfig struct {
+	// Annotate creates annotated tags whose message is the changelog since the previous tag
+	Annotate bool `yaml:"annotate"`
+
+	// Sign signs the annotated tags with user.signingkey, gpg.format selects a gpg or ssh key
+	Sign bool `yaml:"sign"`
+
+	// Channels are the pre-release channels of the tag command, default: alpha and beta
+	Channels TagChannels `yaml:"channels"`
+}
+
+// ReleaseChannel tags the planned version without a pre-release, it is always offered after the channels
+const ReleaseChannel = "release"
+
+// TagNumbering is how the pre-releases of a channel are numbered
+type TagNumbering string
+
+const (
+	// CounterNumbering counts the pre-releases of a version, e.g. v1.2.0-rc.1, v1.2.0-rc.2
+	CounterNumbering TagNumbering = "counter"
+
+	// DateNumbering counts the pre-releases of a day, e.g. v1.2.0-nightly.20261017.1
+	DateNumbering TagNumbering = "date"
+)
+
+// TagChannel is a pre-release channel, its name is the first identifier of the pre-release
+type TagChannel struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+
+	// Numbering is counter or date, default: counter
+	Numbering TagNumbering `yaml:"numbering"`
+}
+
+// TagChannels are a yaml list of channels, or a string of FASTCOMMIT_TAG_CHANNELS in the local env file
+type TagChannels []*TagChannel
+
+func (c *TagChannels) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return value.Decode((*[]*TagChannel)(c))
+	}
+
+	channels, err := ParseTagChannels(value.Value)
+	if err != nil {
+		return err
+	}
+	*c = channels
+	return nil
+}
+
+// ParseTagChannels parses the comma separated channels with an optional numbering, e.g. rc,nightly:date
+func ParseTagChannels(s string) (TagChannels, error) {
+	var channels TagChannels
+	for _, item := range strings.Split(s, ",") {
+		name, numbering, _ := strings.Cut(strings.TrimSpace(item), ":")
+		if name == "" {
+			continue
+		}
+
+		channel := &TagChannel{Name: name, Numbering: TagNumbering(strings.TrimSpace(numbering))}
+		if err := channel.Validate(); err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+var defaultTagChannels = []*TagChannel{
+	{Name: "alpha", Description: "early testing"},
+	{Name: "beta", Description: "feature complete"},
+}
+
+// GetChannels returns the configured channels, alpha and beta when none is configured
+func (c *TagConfig) GetChannels() []*TagChannel {
+	if c == nil || len(c.Channels) == 0 {
+		return defaultTagChannels
+	}
+	return c.Channels
+}
+
+// Validate checks that the names are unique identifiers of a pre-release and the numbering is known
+func (c *TagChannel) Validate() error {
+	if !channelNameRegexp.MatchString(c.Name) {
+		return errors.Errorf("invalid tag channel name %q, use letters, digits and hyphens", c.Name)
+	}
+
+	if c.Name == ReleaseChannel {
+		return errors.Errorf("the tag channel %q is reserved", ReleaseChannel)
+	}
+
+	switch c.Numbering {
+	case "", CounterNumbering, DateNumbering:
+		return nil
+	default:
+		return errors.Errorf("unknown numbering %q of the tag channel %s, use counter or date", c.Numbering, c.Name)
+	}
+}
+
+// Prerelease is the pre-release without the counter, e.g. rc or nightly.20261017
+func (c *TagChannel) Prerelease(now time.Time) string {
+	if c.Numbering == DateNumbering {
+		return c.Name + "." + now.Format("20060102")
+	}
+	return c.Name
+}
+
+// NextTag returns the next pre-release of core in the channel, core is the next patch of the max tag when it is nil
+func (c *TagChannel) NextTag(core *semver.Version, tags []*semver.Version, now time.Time) *semver.Version {
+	return GetNextTag(c.Prerelease(now), core, tags)
+}
+
+var channelNameRegexp = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// TagOptions are the options of git tag, nil creates a lightweight tag
+type TagOptions struct {
+	// Message is the message of the annotated tag
+	Message string
+	Sign    bool
+}
+
+func (o *TagOptions) args(ver string) []string {
+	if o == nil || (o.Message == "" && !o.Sign) {
+		return []string{"tag", ver}
+	}
+
+	// the default cleanup would strip the markdown headings as comments
+	var args = []string{"tag", lo.Ternary(o.Sign, "-s", "-a"), "--cleanup=verbatim", "-m", lo.CoalesceOrEmpty(o.Message, ver)}
+	return append(args, ver)
+}
+
+// GetTagOptions returns the options of the tag by the config, the message of an annotated or signed tag is
+// the changelog of the commits since the previous tag, of the module when it is not nil, nil when the tag is lightweight
+func GetTagOptions(ctx context.Context, cfg *TagConfig, tag string, m *Module) (*TagOptions, error) {
+	if cfg == nil || (!cfg.Annotate && !cfg.Sign) {
+		return nil, nil
+	}
+
+	msg, err := GetTagMessage(ctx, tag, m)
+	if err != nil {
+		return nil, err
+	}
+	return &TagOptions{Message: msg, Sign: cfg.Sign}, nil
+}
+
+// GetTagMessage returns the release notes of the commits between the previous tag and HEAD,
+// only the commits which changed the files of the module when it is not nil
+func GetTagMessage(ctx context.Context, tag string, m *Module) (string, error) {
+	var prefix string
+	var tags []*semver.Version
+	var pathspecs []string
+	if m != nil {
+		prefix, tags = m.TagPrefix(), m.Tags(ctx)
+		pathspecs = append([]string{"--"}, m.Pathspecs()...)
+	} else {
+		tags = GetAllGitTags(ctx)
+	}
+
+	var from string
+	var rev = "HEAD"
+	if prev := GetPreviousTag(tags, strings.TrimPrefix(tag, prefix)); prev != nil {
+		from = prefix + prev.Original()
+		rev = from + "..HEAD"
+	}
+
+	commits, err := GetConventionalCommits(ctx, append([]string{rev}, pathspecs...)...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the release notes of %s", tag)
+	}
+
+	chglog := NewChangelog(tag, from, "HEAD", "", commits)
+	return fmt.Sprintf("Release %s\n\n%s\n", tag, strings.TrimSpace(chglog.Notes())), nil
+}
+
+// GetPreviousTag returns the highest tag lower than ver, nil when there is none or ver is not a version
+func GetPreviousTag(tags []*semver.Version, ver string) *semver.Version {
+	cur, err := semver.NewSemver(ver)
+	if err != nil {
+		return nil
+	}
+
+	tags = lo.Filter(tags, func(item *semver.Version, index int) bool { return item.LessThan(cur) })
+	return lo.MaxBy(tags, func(a *semver.Version, b *semver.Version) bool { return a.GreaterThan(b) })
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTagOptions(t *testing.T) {
+	cases := []struct {
+		opts *TagOptions
+		want []string
+	}{
+		{want: []string{"tag", "v1.0.0"}},
+		{opts: &TagOptions{Message: "notes"}, want: []string{"tag", "-a", "--cleanup=verbatim", "-m", "notes", "v1.0.0"}},
+		{opts: &TagOptions{Sign: true}, want: []string{"tag", "-s", "--cleanup=verbatim", "-m", "v1.0.0", "v1.0.0"}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, c.opts.args("v1.0.0"))
+	}
+}
+
+func TestGetPreviousTag(t *testing.T) {
+	var tags []*semver.Version
+	for _, tag := range []string{"v1.2.0", "v1.3.0-alpha.1", "v1.3.0", "v0.9.0"} {
+		tags = append(tags, semver.Must(semver.NewSemver(tag)))
+	}
+
+	for ver, want := range map[string]string{"v1.3.0": "v1.3.0-alpha.1", "v1.3.0-alpha.2": "v1.3.0-alpha.1", "v1.2.1": "v1.2.0", "v1.4.0": "v1.3.0"} {
+		if prev := GetPreviousTag(tags, ver); assert.NotNil(t, prev, ver) {
+			assert.Equal(t, want, prev.Original(), ver)
+		}
+	}
+
+	assert.Nil(t, GetPreviousTag(tags, "v0.1.0"))
+}
+
+func TestTagChannel(t *testing.T) {
+	var tags []*semver.Version
+	for _, tag := range []string{"v1.2.0", "v1.3.0-rc.9", "v1.3.0-rc.10", "v1.3.0-rc-fix.20", "v1.3.0-nightly.20261016.4", "v1.3.0-nightly.20261017.2", "v1.4.0-dev.1"} {
+		tags = append(tags, semver.Must(semver.NewSemver(tag)))
+	}
+
+	core := semver.Must(semver.NewSemver("v1.3.0"))
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		channel *TagChannel
+		want    string
+	}{
+		{channel: &TagChannel{Name: "rc"}, want: "v1.3.0-rc.11"},
+		{channel: &TagChannel{Name: "nightly", Numbering: DateNumbering}, want: "v1.3.0-nightly.20261017.3"},
+		{channel: &TagChannel{Name: "beta"}, want: "v1.3.0-beta.1"},
+		{channel: &TagChannel{Name: "dev"}, want: "v1.4.0-dev.2"},
+	}
+
+	for _, c := range cases {
+		require.NoError(t, c.channel.Validate())
+		assert.Equal(t, c.want, c.channel.NextTag(core, tags, now).Original(), c.channel.Name)
+	}
+
+	for _, c := range []*TagChannel{{Name: "release"}, {Name: "a.b"}, {Name: "rc", Numbering: "weekly"}} {
+		assert.Error(t, c.Validate(), c.Name)
+	}
+
+	channels := (*TagConfig)(nil).GetChannels()
+	require.Len(t, channels, 2)
+	assert.Equal(t, "alpha", channels[0].Name)
+}
+
+func TestTagChannelsYAML(t *testing.T) {
+	for data, want := range map[string]TagChannels{
+		"channels: [{name: rc}, {name: nightly, numbering: date}]": {{Name: "rc"}, {Name: "nightly", Numbering: DateNumbering}},
+		"channels: rc, nightly:date,":                              {{Name: "rc"}, {Name: "nightly", Numbering: DateNumbering}},
+		"channels: ":                                               nil,
+	} {
+		var cfg TagConfig
+		require.NoError(t, yaml.Unmarshal([]byte(data), &cfg), data)
+		assert.Equal(t, want, cfg.Channels, data)
+	}
+
+	var cfg TagConfig
+	assert.Error(t, yaml.Unmarshal([]byte("channels: rc:weekly"), &cfg))
+}
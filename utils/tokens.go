@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenCounter counts the llm tokens of the texts, CountTokens is the estimate without a provider
+type TokenCounter func(texts ...string) int
+
+// ScaledTokenCounter estimates the parts of sample in proportion to its exact token count,
+// so that the provider counts the sample once instead of every part of it
+func ScaledTokenCounter(sample string, exact int) TokenCounter {
+	estimate := CountTokens(sample)
+	if estimate <= 0 || exact <= 0 {
+		return CountTokens
+	}
+
+	return func(texts ...string) int {
+		return (CountTokens(texts...)*exact + estimate - 1) / estimate
+	}
+}
+
+// CountTokens estimates the number of llm tokens of the texts,
+// runs of letters and digits count one token per four characters, punctuation and non latin characters count one token each
+func CountTokens(texts ...string) int {
+	var tokens int
+	for _, text := range texts {
+		tokens += countTokens(text, -1)
+	}
+	return tokens
+}
+
+// Ellipse truncates s to at most maxTokens tokens
+func Ellipse(s string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	end := countTokens(s, maxTokens)
+	if end >= len(s) {
+		return s
+	}
+	return s[:end] + "..."
+}
+
+// countTokens returns the token count of s, if limit >= 0 it returns the byte offset where limit tokens end instead
+func countTokens(s string, limit int) int {
+	var tokens, word int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if word%4 == 0 {
+				tokens++
+			}
+			word++
+		case unicode.IsSpace(r):
+			word = 0
+		default:
+			word = 0
+			tokens++
+		}
+
+		if limit >= 0 && tokens > limit {
+			return i
+		}
+		i += size
+	}
+
+	if limit >= 0 {
+		return len(s)
+	}
+	return tokens
+}
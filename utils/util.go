@@ -19,12 +19,10 @@ import (
 	"github.com/pubgo/funk/v2/assert"
 	"github.com/pubgo/funk/v2/errors"
 	"github.com/pubgo/funk/v2/log"
-	"github.com/pubgo/funk/v2/recovery"
 	"github.com/pubgo/funk/v2/result"
 	"github.com/pubgo/funk/v2/typex"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
-	"github.com/tidwall/match"
 	_ "github.com/tidwall/match"
 	"mvdan.cc/sh/v3/shell"
 
@@ -55,20 +53,32 @@ func GetAllRemoteTags(ctx context.Context) []*semver.Version {
 }
 
 func GetAllGitTags(ctx context.Context) []*semver.Version {
-	log.Info().Msg("get all tags")
+	return GetPrefixGitTags(ctx, "")
+}
+
+// GetPrefixGitTags returns the versions of the tags prefix+v*, e.g. the tags pkg/foo/v1.2.3 of the prefix pkg/foo/,
+// the prefix is stripped from the versions
+func GetPrefixGitTags(ctx context.Context, prefix string) []*semver.Version {
+	log.Info().Str("prefix", prefix).Msg("get all tags")
 	var tagText = strings.TrimSpace(ShellExecOutput(ctx, "git", "tag").Unwrap())
 	var tags = strings.Split(tagText, "\n")
 	var versions = make([]*semver.Version, 0, len(tags))
 
 	for _, tag := range tags {
 		tag = strings.TrimSpace(tag)
-		if !strings.HasPrefix(tag, "v") {
+		if !strings.HasPrefix(tag, prefix+"v") {
+			continue
+		}
+
+		// pkg/foo/bar/v1.0.0 is a tag of the nested module pkg/foo/bar, not of pkg/foo
+		tag = strings.TrimPrefix(tag, prefix)
+		if strings.Contains(tag, "/") {
 			continue
 		}
 
 		vv, err := semver.NewSemver(tag)
 		if err != nil {
-			log.Err(err).Str("tag", tag).Msg("failed to parse git tag")
+			log.Err(err).Str("tag", prefix+tag).Msg("failed to parse git tag")
 			assert.Must(err)
 		}
 		versions = append(versions, vv)
@@ -100,25 +110,42 @@ func GetNextReleaseTag(tags []*semver.Version) *semver.Version {
 	return curMaxVer.Core()
 }
 
-func GetNextTag(pre string, tags []*semver.Version) *semver.Version {
-	if len(tags) == 0 {
-		return semver.Must(semver.NewSemver("v0.0.1"))
+// GetNextTag returns the next pre-release of core in the channel pre, e.g. v1.2.0-rc.3,
+// pre may have several identifiers, e.g. nightly.20261017, the counter is the last identifier.
+// Core is the next patch of the max tag when it is nil, a channel which is ahead of core continues its version.
+func GetNextTag(pre string, core *semver.Version, tags []*semver.Version) *semver.Version {
+	if core == nil {
+		core = lo.Ternary(len(tags) == 0, semver.Must(semver.NewSemver("v0.0.1")), GetNextGitMaxTag(tags))
 	}
 
-	var maxVer = GetNextGitMaxTag(tags)
-	var curMaxVer = typex.DoBlock1(func() *semver.Version {
-		tags = lo.Filter(tags, func(item *semver.Version, index int) bool { return strings.Contains(item.String(), pre) })
-		return lo.MaxBy(tags, func(a *semver.Version, b *semver.Version) bool { return a.Compare(b) > 0 })
-	})
+	var cur *semver.Version
+	var counter int
+	for _, tag := range tags {
+		n, ok := channelCounter(tag, pre)
+		if !ok || tag.Core().LessThan(core.Core()) {
+			continue
+		}
+
+		if cur == nil || tag.Core().GreaterThan(cur.Core()) || (tag.Core().Equal(cur.Core()) && n > counter) {
+			cur, counter = tag, n
+		}
+	}
 
-	var ver string
-	if curMaxVer != nil && curMaxVer.Core().GreaterThanOrEqual(maxVer) {
-		ver = strings.ReplaceAll(curMaxVer.Prerelease(), fmt.Sprintf("%s.", pre), "")
-		ver = fmt.Sprintf("v%s-%s.%d", curMaxVer.Core().String(), pre, assert.Must1(strconv.Atoi(ver))+1)
-	} else {
-		ver = fmt.Sprintf("v%s-%s.1", maxVer.Core().String(), pre)
+	if cur == nil {
+		return assert.Must1(semver.NewSemver(fmt.Sprintf("v%s-%s.1", core.Core().String(), pre)))
 	}
-	return assert.Must1(semver.NewSemver(ver))
+	return assert.Must1(semver.NewSemver(fmt.Sprintf("v%s-%s.%d", cur.Core().String(), pre, counter+1)))
+}
+
+// channelCounter returns N of the pre-release pre.N of the tag
+func channelCounter(tag *semver.Version, pre string) (int, bool) {
+	rest, ok := strings.CutPrefix(tag.Prerelease(), pre+".")
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(rest)
+	return n, err == nil
 }
 
 func GetNextGitMaxTag(tags []*semver.Version) *semver.Version {
@@ -169,20 +196,22 @@ func IsHelp() bool {
 	return false
 }
 
-func GitPush(ctx context.Context, args ...string) string {
+// GitPush runs git push --porcelain, the result tells for every ref whether it was pushed or why it was rejected
+func GitPush(ctx context.Context, args ...string) *PushResult {
 	now := time.Now()
-	args = append([]string{"git", "push"}, args...)
-	output := result.Async(func() result.Result[string] { return ShellExecOutput(ctx, args...) })
+	args = append([]string{"push", "--porcelain"}, args...)
+	log.Info().Msgf("shell: git %s", strings.Join(args, " "))
+	output := result.Async(func() result.Result[*PushResult] { return result.OK(ParsePushResult(RunGit(ctx, args...))) })
 	time.Sleep(time.Millisecond * 20)
 
 	spin := spinner.New(spinner.CharSets[35], 100*time.Millisecond, func(s *spinner.Spinner) {
-		s.Prefix = strings.Join(args, " ") + ":"
+		s.Prefix = "git " + strings.Join(args, " ") + ":"
 	})
 	spin.Start()
 	res := output.Await(ctx).Unwrap()
 	spin.Stop()
-	if res != "" {
-		log.Info().Str("dur", time.Since(now).String()).Msgf("shell result: \n%s\n", res)
+	if res.Output != "" {
+		log.Info().Str("dur", time.Since(now).String()).Str("status", string(res.Status())).Msgf("shell result: \n%s\n", res.Output)
 	}
 	return res
 }
@@ -202,12 +231,17 @@ func ShellExec(ctx context.Context, args ...string) (err error) {
 func ShellExecOutput(ctx context.Context, args ...string) (r result.Result[string]) {
 	defer result.Recovery(&r, func(err error) error {
 		if exitErr, ok := errors.AsA[exec.ExitError](err); ok && exitErr.String() == "signal: interrupt" {
-			os.Exit(1)
+			return errors.Wrap(context.Canceled, exitErr.String())
 		}
 
 		return err
 	})
 
+	// git runs without a shell, so that the args, e.g. commit messages, need no quoting
+	if len(args) > 0 && args[0] == "git" {
+		return gitOutput(ctx, args[1:]...)
+	}
+
 	sh := getShell()
 	if sh != "" {
 		args = []string{sh, "-c", fmt.Sprintf(`'%s'`, strings.Join(args, " "))}
@@ -229,12 +263,16 @@ func ShellExecOutput(ctx context.Context, args ...string) (r result.Result[strin
 	return r.WithValue(strings.TrimSpace(string(output)))
 }
 
-func IsRemoteTagExist(err string) bool {
-	return strings.Contains(err, "[rejected]") && strings.Contains(err, "tag already exists")
-}
-
-func IsRemotePushCommitFailed(err string) bool {
-	return strings.Contains(err, "[rejected]") && strings.Contains(err, "failed to push some refs to")
+// gitOutput returns stdout and stderr of git, git push and pull report on stderr,
+// every non-zero exit code is an error, the callers which expect exit code 1 run RunGit and check IsGitExitCode
+func gitOutput(ctx context.Context, args ...string) (r result.Result[string]) {
+	log.Info().Msgf("shell: git %s", strings.Join(args, " "))
+	res, err := RunGit(ctx, args...)
+	if err != nil {
+		log.Err(err, ctx).Msg("git error\n" + res.Output())
+		return r.WithErr(err)
+	}
+	return r.WithValue(res.Output())
 }
 
 func Spin[T any](name string, do func() result.Result[T]) (r result.Result[T]) {
@@ -252,27 +290,32 @@ func Spin[T any](name string, do func() result.Result[T]) (r result.Result[T]) {
 //
 // nothing to commit, working tree clean
 
-func PreGitPush(ctx context.Context) string {
-	defer recovery.Exit()
+// PreGitPush pushes the local commits of a clean work tree, it returns nil when nothing was pushed
+func PreGitPush(ctx context.Context) (*PushResult, error) {
+	status, err := GetRepoStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	isDirty := IsDirty().Unwrap()
-	if isDirty {
-		return ""
+	if status.IsDirty() {
+		return nil, nil
 	}
 
-	res := ShellExecOutput(ctx, "git", "status").Unwrap()
-	needPush := strings.Contains(res, "Your branch is ahead of") && strings.Contains(res, "(use \"git push\" to publish your local commits)")
-	if !needPush {
-		needPush =
-			match.Match(res, "*Your branch and '*' have diverged*") &&
-				strings.Contains(ShellExecOutput(ctx, "git", "reflog", "-1").Unwrap(), "(amend)")
+	// a diverged branch whose last commit was amended is pushed with the lease
+	needPush := status.NeedPush()
+	if !needPush && status.Diverged() {
+		reflog, err := ShellExecOutput(ctx, "git", "reflog", "-1").UnwrapErr()
+		if err != nil {
+			return nil, err
+		}
+		needPush = strings.Contains(reflog, "(amend)")
 	}
 
 	if !needPush {
-		return ""
+		return nil, nil
 	}
 
-	return GitPush(ctx, "--force-with-lease", "origin", GetBranchName())
+	return GitPush(ctx, "--force-with-lease", "origin", GetBranchName()), nil
 }
 
 var GetBranchName = sync.OnceValue(func() string { return GetCurrentBranch().Unwrap() })
@@ -310,21 +353,20 @@ func getShell() string {
 	return ""
 }
 
-func IsStatusNeedPush(msg string) bool {
-	var pattern = `
-*Your branch is ahead of '*' by * commits.
-  (use "git push" to publish your local commits)*
-`
-
-	return match.Match(msg, pattern)
-}
-
-var editors = []string{"zed", "subl", "vim", "code", "open"}
+// editors are tried in order when $VISUAL and $EDITOR are not set, the gui editors wait until the file is closed
+var editors = []string{"zed -w", "code -w", "subl -w", "vim", "nano", "vi", "open -W"}
 
+// GetEditor returns $VISUAL, $EDITOR or the first installed editor, it may have arguments, e.g. "code -w"
 func GetEditor() (r result.Result[string]) {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return r.WithValue(editor)
+		}
+	}
+
 	for _, editor := range editors {
-		_, err := exec.LookPath(editor)
-		if err == nil {
+		name, _, _ := strings.Cut(editor, " ")
+		if _, err := exec.LookPath(name); err == nil {
 			return r.WithValue(editor)
 		}
 	}
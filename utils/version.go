@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/pubgo/funk/v2/errors"
+	"github.com/samber/lo"
+)
+
+// Bump is the segment of the version a release increments
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// ConventionalCommit is a commit whose subject follows type(scope)!: description
+type ConventionalCommit struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+
+	// Breaking is set by the ! after the type, or by a BREAKING CHANGE trailer or footer
+	Breaking bool
+
+	Message *CommitMessage
+}
+
+// Bump returns the bump the commit asks for, feat is minor, fix and perf are patch, a breaking change is major
+func (c *ConventionalCommit) Bump() Bump {
+	switch {
+	case c.Breaking:
+		return BumpMajor
+	case c.Type == "feat":
+		return BumpMinor
+	case c.Type == "fix" || c.Type == "perf":
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+// ParseConventionalCommit parses the commit message, false when the subject is not a conventional commit
+func ParseConventionalCommit(hash string, msg string) (*ConventionalCommit, bool) {
+	cm := ParseCommitMessage(msg)
+	m := subjectRegexp.FindStringSubmatch(cm.Subject)
+	if m == nil {
+		return nil, false
+	}
+
+	var commit = &ConventionalCommit{
+		Hash:        hash,
+		Type:        strings.ToLower(m[2]),
+		Scope:       m[3],
+		Description: m[5],
+		Breaking:    m[4] == "!",
+		Message:     cm,
+	}
+
+	for _, t := range cm.Trailers {
+		if t.Key == "BREAKING CHANGE" || t.Key == "BREAKING-CHANGE" {
+			commit.Breaking = true
+		}
+	}
+
+	// the footer is not a trailer paragraph when it is followed by text
+	for _, line := range strings.Split(cm.Body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE: ") || strings.HasPrefix(line, "BREAKING-CHANGE: ") {
+			commit.Breaking = true
+		}
+	}
+	return commit, true
+}
+
+// VersionPlan is the next release version inferred from the conventional commits since the latest release
+type VersionPlan struct {
+	// Current is the latest release tag, nil before the first release
+	Current *semver.Version
+	Next    *semver.Version
+
+	// Bump is the segment Next increments, a patch when no commit asks for a bump, none when Next is Current
+	Bump Bump
+
+	// Changed is the number of all commits since Current, conventional or not
+	Changed int
+
+	// Commits are the conventional commits since Current, the other commits are skipped
+	Commits []*ConventionalCommit
+}
+
+// Unchanged reports whether there is no commit since the latest release, Next is Current then
+func (p *VersionPlan) Unchanged() bool { return p.Current != nil && p.Changed == 0 }
+
+// PlanVersion bumps current by the highest bump of the commits, a patch when no commit asks for one,
+// none when no commit was changed since current. Before 1.0.0 a breaking change bumps the minor segment,
+// the first release is 0.1.0 or 0.0.1 for fixes.
+func PlanVersion(current *semver.Version, changed int, commits []*ConventionalCommit) *VersionPlan {
+	var plan = &VersionPlan{Current: current, Changed: changed, Commits: commits}
+	if plan.Unchanged() {
+		plan.Next = current
+		return plan
+	}
+
+	plan.Bump = BumpPatch
+	for _, c := range commits {
+		plan.Bump = max(plan.Bump, c.Bump())
+	}
+
+	var major, minor, patch int
+	if current != nil {
+		segments := current.Core().Segments()
+		major, minor, patch = segments[0], segments[1], segments[2]
+	}
+
+	if major == 0 && plan.Bump == BumpMajor {
+		plan.Bump = BumpMinor
+	}
+
+	switch plan.Bump {
+	case BumpMajor:
+		major, minor, patch = major+1, 0, 0
+	case BumpMinor:
+		minor, patch = minor+1, 0
+	default:
+		patch++
+	}
+
+	plan.Next = semver.Must(semver.NewSemver(fmt.Sprintf("v%d.%d.%d", major, minor, patch)))
+	return plan
+}
+
+// GetLatestReleaseTag returns the highest tag without a pre-release, nil when there is none
+func GetLatestReleaseTag(tags []*semver.Version) *semver.Version {
+	tags = lo.Filter(tags, func(item *semver.Version, index int) bool { return item.Prerelease() == "" })
+	return lo.MaxBy(tags, func(a *semver.Version, b *semver.Version) bool { return a.GreaterThan(b) })
+}
+
+// GetVersionPlan plans the next release from the commits between the latest release tag and HEAD,
+// the first release is planned before the first commit
+func GetVersionPlan(ctx context.Context) (*VersionPlan, error) {
+	if _, err := RunGit(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return PlanVersion(nil, 0, nil), nil
+	}
+
+	current := GetLatestReleaseTag(GetAllGitTags(ctx))
+
+	var rev = "HEAD"
+	if current != nil {
+		rev = current.Original() + "..HEAD"
+	}
+
+	changed, err := CountCommits(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := GetConventionalCommits(ctx, rev)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the commits since the latest release")
+	}
+	return PlanVersion(current, changed, commits), nil
+}
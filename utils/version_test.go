@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pubgo/fastcommit/utils/gittest"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	cases := []struct {
+		msg  string
+		typ  string
+		bump Bump
+	}{
+		{msg: "feat(llm): add streaming", typ: "feat", bump: BumpMinor},
+		{msg: "fix: trim the tag", typ: "fix", bump: BumpPatch},
+		{msg: "perf: cache the tags", typ: "perf", bump: BumpPatch},
+		{msg: "docs: sync strategy", typ: "docs", bump: BumpNone},
+		{msg: "refactor(git)!: drop ShellExec", typ: "refactor", bump: BumpMajor},
+		{msg: "feat: request\n\nBREAKING CHANGE: Provider.Generate takes a request", typ: "feat", bump: BumpMajor},
+		{msg: "fix: x\n\nBREAKING CHANGE: y\n\nmore text", typ: "fix", bump: BumpMajor},
+	}
+
+	for _, c := range cases {
+		commit, ok := ParseConventionalCommit("abc", c.msg)
+		require.True(t, ok, "%q is not parsed", c.msg)
+		assert.Equal(t, c.typ, commit.Type, c.msg)
+		assert.Equal(t, c.bump, commit.Bump(), c.msg)
+	}
+
+	_, ok := ParseConventionalCommit("abc", "Merge branch 'main'")
+	assert.False(t, ok, "a merge commit is parsed as a conventional commit")
+}
+
+func TestPlanVersion(t *testing.T) {
+	commit := func(msg string) *ConventionalCommit {
+		c, _ := ParseConventionalCommit("abc", msg)
+		return c
+	}
+
+	cases := []struct {
+		current string
+		changed int
+		commits []*ConventionalCommit
+		want    string
+		bump    Bump
+	}{
+		{current: "v1.2.3", commits: []*ConventionalCommit{commit("fix: a"), commit("feat: b")}, want: "v1.3.0", bump: BumpMinor},
+		{current: "v1.2.3", commits: []*ConventionalCommit{commit("feat!: a"), commit("fix: b")}, want: "v2.0.0", bump: BumpMajor},
+		{current: "v1.2.3", commits: []*ConventionalCommit{commit("fix: a")}, want: "v1.2.4", bump: BumpPatch},
+		{current: "v1.2.3", commits: []*ConventionalCommit{commit("docs: a")}, want: "v1.2.4", bump: BumpPatch},
+		// the commits which are not conventional, e.g. of gitmoji, are changes of a patch
+		{current: "v1.2.3", changed: 2, want: "v1.2.4", bump: BumpPatch},
+		{current: "v1.2.3", want: "v1.2.3", bump: BumpNone},
+		{current: "v0.3.1", commits: []*ConventionalCommit{commit("feat!: a")}, want: "v0.4.0", bump: BumpMinor},
+		{current: "v0.3.1", commits: []*ConventionalCommit{commit("feat: a")}, want: "v0.4.0", bump: BumpMinor},
+		{commits: []*ConventionalCommit{commit("feat: a")}, want: "v0.1.0", bump: BumpMinor},
+		{commits: []*ConventionalCommit{commit("fix: a")}, want: "v0.0.1", bump: BumpPatch},
+		{want: "v0.0.1", bump: BumpPatch},
+	}
+
+	for _, c := range cases {
+		var current *semver.Version
+		if c.current != "" {
+			current = semver.Must(semver.NewSemver(c.current))
+		}
+
+		plan := PlanVersion(current, max(c.changed, len(c.commits)), c.commits)
+		assert.Equal(t, c.want, plan.Next.Original(), c.current)
+		assert.Equal(t, c.bump, plan.Bump, c.current)
+		assert.Equal(t, bumpOf(current, plan.Next), plan.Bump, "%s: the bump matches %s", c.current, plan.Next.Original())
+
+		// no commit since the release plans no release
+		assert.Equal(t, c.want == c.current, plan.Unchanged(), c.current)
+	}
+}
+
+// bumpOf is the highest segment which differs between current and next, current is 0.0.0 when it is nil
+func bumpOf(current, next *semver.Version) Bump {
+	var from = []int{0, 0, 0}
+	if current != nil {
+		from = current.Core().Segments()
+	}
+
+	to := next.Core().Segments()
+	for i, bump := range []Bump{BumpMajor, BumpMinor, BumpPatch} {
+		if from[i] != to[i] {
+			return bump
+		}
+	}
+	return BumpNone
+}
+
+func TestGetVersionPlan(t *testing.T) {
+	ctx := context.Background()
+	repo := gittest.New(t)
+	useGitDir(t, repo.Dir)
+
+	// the first commit of a new repository is the first release
+	plan, err := GetVersionPlan(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, plan.Current)
+	assert.Equal(t, "v0.0.1", plan.Next.Original())
+	assert.Empty(t, plan.Commits)
+
+	repo.Commit("a.txt", "a", "feat: a")
+	repo.Git("tag", "v1.0.0")
+	plan, err = GetVersionPlan(ctx)
+	require.NoError(t, err)
+	assert.True(t, plan.Unchanged())
+	assert.Equal(t, "v1.0.0", plan.Next.Original())
+
+	repo.Commit("b.txt", "b", ":sparkles: b")
+	plan, err = GetVersionPlan(ctx)
+	require.NoError(t, err)
+	assert.False(t, plan.Unchanged())
+	assert.Equal(t, 1, plan.Changed)
+	assert.Equal(t, "v1.0.1", plan.Next.Original())
+}
+
+func TestGetLatestReleaseTag(t *testing.T) {
+	var tags []*semver.Version
+	for _, tag := range []string{"v1.2.0", "v1.3.0-alpha.2", "v1.2.1", "v0.9.0"} {
+		tags = append(tags, semver.Must(semver.NewSemver(tag)))
+	}
+
+	assert.Equal(t, "v1.2.1", GetLatestReleaseTag(tags).Original())
+
+	core := semver.Must(semver.NewSemver("v1.3.0"))
+	assert.Equal(t, "v1.3.0-alpha.3", GetNextTag("alpha", core, tags).Original())
+	assert.Equal(t, "v1.3.0-beta.1", GetNextTag("beta", core, tags).Original())
+}
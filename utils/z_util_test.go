@@ -12,17 +12,20 @@ import (
 )
 
 func TestErrTagExists(t *testing.T) {
-	var errMsg = `
-To github.com:pubgo/funk.git
- ! [rejected]          v0.5.69-alpha.23 -> v0.5.69-alpha.23 (already exists)
-error: failed to push some refs to 'github.com:pubgo/funk.git'
-hint: Updates were rejected because the tag already exists in the remote.`
-	assert.Equal(t, utils.IsRemoteTagExist(errMsg), true)
+	var res = &utils.GitResult{
+		Stdout: "To github.com:pubgo/funk.git\n" +
+			"!\trefs/tags/v0.5.69-alpha.23:refs/tags/v0.5.69-alpha.23\t[rejected] (already exists)\n" +
+			"Done\n",
+		Stderr: "error: failed to push some refs to 'github.com:pubgo/funk.git'\n" +
+			"hint: Updates were rejected because the tag already exists in the remote.",
+		ExitCode: 1,
+	}
+	assert.Equal(t, utils.ParsePushResult(res, nil).Status(), utils.PushTagExists)
 }
 
 func TestMatch(t *testing.T) {
 	var txt = `123  Your branch and 'origin/feat/genai' have diverged 123`
 	t.Log(match.Match(txt, fmt.Sprintf("*Your branch and '*feat/genai' have diverged*")))
 
-	t.Log(strings.Contains(utils.ShellExecOutput(context.Background(), "git", "reflog", "-1").Must(), "(amend)"))
+	t.Log(strings.Contains(utils.ShellExecOutput(context.Background(), "git", "reflog", "-1").Unwrap(), "(amend)"))
 }